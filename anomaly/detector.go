@@ -0,0 +1,204 @@
+package anomaly
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// minBaselineBuckets is the fewest buckets an orchestrator needs before the latest bucket can be compared with its baseline
+const minBaselineBuckets = 3
+
+// Config holds the thresholds used by the detector
+type Config struct {
+	// BucketSize is the length of a single bucket of the time series
+	BucketSize time.Duration
+	// RunInterval is the time between two runs, every bucket completed during it is checked
+	RunInterval time.Duration
+	// BaselineBuckets is the number of buckets before the latest one an orchestrator is compared with
+	BaselineBuckets int
+	// SuccessRateDrop is the drop in success rate, between 0 and 1, that is reported as an anomaly
+	SuccessRateDrop float64
+	// RTTFactor is how many times slower than expected the round trip time has to be to be reported as an anomaly
+	RTTFactor float64
+	// SustainedBuckets is the number of consecutive buckets an orchestrator has to be worse than the network
+	SustainedBuckets int
+	// FlapTransitions is the number of changes between healthy and unhealthy that is reported as flapping
+	FlapTransitions int
+	// HealthySuccessRate is the lowest success rate a bucket is considered healthy with
+	HealthySuccessRate float64
+}
+
+// DefaultConfig returns the detector thresholds configured through the environment
+func DefaultConfig() Config {
+	return Config{
+		BucketSize:         time.Duration(common.EnvOrDefault("ANOMALY_BUCKET_MINUTES", 60).(int)) * time.Minute,
+		RunInterval:        time.Duration(common.EnvOrDefault("ANOMALY_RUN_INTERVAL_MINUTES", 60).(int)) * time.Minute,
+		BaselineBuckets:    common.EnvOrDefault("ANOMALY_BASELINE_BUCKETS", 24).(int),
+		SuccessRateDrop:    float64(common.EnvOrDefault("ANOMALY_SUCCESS_RATE_DROP_PCT", 20).(int)) / 100,
+		RTTFactor:          float64(common.EnvOrDefault("ANOMALY_RTT_FACTOR_PCT", 200).(int)) / 100,
+		SustainedBuckets:   common.EnvOrDefault("ANOMALY_SUSTAINED_BUCKETS", 3).(int),
+		FlapTransitions:    common.EnvOrDefault("ANOMALY_FLAP_TRANSITIONS", 4).(int),
+		HealthySuccessRate: float64(common.EnvOrDefault("ANOMALY_HEALTHY_SUCCESS_RATE_PCT", 90).(int)) / 100,
+	}
+}
+
+// Point is the performance of an orchestrator during a single bucket
+type Point struct {
+	Start              time.Time
+	SuccessRate        float64
+	RoundTripTime      float64
+	NetworkSuccessRate float64
+}
+
+// Series is the performance of an orchestrator in a region for a job type and pipeline/model, ordered by time
+type Series struct {
+	Orchestrator string
+	Region       string
+	JobType      string
+	Pipeline     string
+	Model        string
+	Points       []*Point
+	// NetworkMedianRTT is the median RTT of the network during the sustained window
+	NetworkMedianRTT float64
+}
+
+// Detect compares the latest buckets of the series with the orchestrator's own baseline and with the network
+// and returns the anomalies found.  The last point of the series is the bucket being checked.
+func Detect(series *Series, config Config) []*models.Anomaly {
+	anomalies := []*models.Anomaly{}
+	if len(series.Points) == 0 {
+		return anomalies
+	}
+
+	anomalies = append(anomalies, detectSuddenDrop(series, config)...)
+	anomalies = append(anomalies, detectSustainedDegradation(series, config)...)
+	if anomaly := detectFlapping(series, config); anomaly != nil {
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies
+}
+
+// detectSuddenDrop compares the latest bucket with the average of the buckets before it
+func detectSuddenDrop(series *Series, config Config) []*models.Anomaly {
+	anomalies := []*models.Anomaly{}
+	latest := series.Points[len(series.Points)-1]
+	baseline := series.Points[:len(series.Points)-1]
+	if len(baseline) > config.BaselineBuckets {
+		baseline = baseline[len(baseline)-config.BaselineBuckets:]
+	}
+	if len(baseline) < minBaselineBuckets {
+		return anomalies
+	}
+
+	var successRate, roundTripTime float64
+	rttSamples := 0
+	for _, p := range baseline {
+		successRate += p.SuccessRate
+		if p.RoundTripTime > 0 {
+			roundTripTime += p.RoundTripTime
+			rttSamples++
+		}
+	}
+	successRate /= float64(len(baseline))
+
+	windowStart, windowEnd := latest.Start, latest.Start.Add(config.BucketSize)
+	if drop := successRate - latest.SuccessRate; drop >= config.SuccessRateDrop {
+		anomalies = append(anomalies, series.newAnomaly(models.AnomalySuddenDrop, models.AnomalyMetricSuccessRate, latest.SuccessRate, successRate, windowStart, windowEnd,
+			fmt.Sprintf("success rate dropped by %.1f%% compared with the previous %d buckets", drop*100, len(baseline))))
+	}
+	if rttSamples >= minBaselineBuckets && latest.RoundTripTime > 0 {
+		roundTripTime /= float64(rttSamples)
+		if latest.RoundTripTime >= roundTripTime*config.RTTFactor {
+			anomalies = append(anomalies, series.newAnomaly(models.AnomalySuddenDrop, models.AnomalyMetricRoundTripTime, latest.RoundTripTime, roundTripTime, windowStart, windowEnd,
+				fmt.Sprintf("round trip time is %.1fx the average of the previous %d buckets", latest.RoundTripTime/roundTripTime, rttSamples)))
+		}
+	}
+	return anomalies
+}
+
+// detectSustainedDegradation checks whether the orchestrator was worse than the network in each of the latest consecutive buckets
+func detectSustainedDegradation(series *Series, config Config) []*models.Anomaly {
+	anomalies := []*models.Anomaly{}
+	if config.SustainedBuckets < 1 || len(series.Points) < config.SustainedBuckets {
+		return anomalies
+	}
+	window := series.Points[len(series.Points)-config.SustainedBuckets:]
+	for i := 1; i < len(window); i++ {
+		if window[i].Start.Sub(window[i-1].Start) != config.BucketSize {
+			return anomalies
+		}
+	}
+
+	successRateDegraded, rttDegraded := true, series.NetworkMedianRTT > 0
+	var successRate, networkSuccessRate, roundTripTime float64
+	for _, p := range window {
+		successRate += p.SuccessRate
+		networkSuccessRate += p.NetworkSuccessRate
+		roundTripTime += p.RoundTripTime
+		if p.NetworkSuccessRate-p.SuccessRate < config.SuccessRateDrop {
+			successRateDegraded = false
+		}
+		if p.RoundTripTime < series.NetworkMedianRTT*config.RTTFactor {
+			rttDegraded = false
+		}
+	}
+	buckets := float64(len(window))
+
+	windowStart, windowEnd := window[0].Start, window[len(window)-1].Start.Add(config.BucketSize)
+	if successRateDegraded {
+		anomalies = append(anomalies, series.newAnomaly(models.AnomalySustainedDegradation, models.AnomalyMetricSuccessRate, successRate/buckets, networkSuccessRate/buckets, windowStart, windowEnd,
+			fmt.Sprintf("success rate was at least %.1f%% below the network for %d consecutive buckets", config.SuccessRateDrop*100, len(window))))
+	}
+	if rttDegraded {
+		anomalies = append(anomalies, series.newAnomaly(models.AnomalySustainedDegradation, models.AnomalyMetricRoundTripTime, roundTripTime/buckets, series.NetworkMedianRTT, windowStart, windowEnd,
+			fmt.Sprintf("round trip time was at least %.1fx the network median for %d consecutive buckets", config.RTTFactor, len(window))))
+	}
+	return anomalies
+}
+
+// detectFlapping counts how often the orchestrator changed between healthy and unhealthy buckets.  The window is
+// the baseline before the latest bucket and the latest bucket, whichever buckets the orchestrator has stats in.
+func detectFlapping(series *Series, config Config) *models.Anomaly {
+	if config.FlapTransitions < 1 {
+		return nil
+	}
+	points := series.Points
+	if len(points) > config.BaselineBuckets+1 {
+		points = points[len(points)-config.BaselineBuckets-1:]
+	}
+
+	transitions := 0
+	for i := 1; i < len(points); i++ {
+		if (points[i].SuccessRate >= config.HealthySuccessRate) != (points[i-1].SuccessRate >= config.HealthySuccessRate) {
+			transitions++
+		}
+	}
+	if transitions < config.FlapTransitions {
+		return nil
+	}
+
+	latest := points[len(points)-1]
+	windowStart := latest.Start.Add(-time.Duration(config.BaselineBuckets) * config.BucketSize)
+	return series.newAnomaly(models.AnomalyFlapping, models.AnomalyMetricSuccessRate, float64(transitions), float64(config.FlapTransitions), windowStart, latest.Start.Add(config.BucketSize),
+		fmt.Sprintf("success rate crossed %.1f%% %d times in %d buckets", config.HealthySuccessRate*100, transitions, len(points)))
+}
+
+func (s *Series) newAnomaly(anomalyType, metric string, observed, expected float64, windowStart, windowEnd time.Time, details string) *models.Anomaly {
+	return &models.Anomaly{
+		Type:         anomalyType,
+		Metric:       metric,
+		Orchestrator: s.Orchestrator,
+		Region:       s.Region,
+		JobType:      s.JobType,
+		Pipeline:     s.Pipeline,
+		Model:        s.Model,
+		Observed:     observed,
+		Expected:     expected,
+		Details:      details,
+		WindowStart:  windowStart.Unix(),
+		WindowEnd:    windowEnd.Unix(),
+	}
+}
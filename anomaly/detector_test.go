@@ -0,0 +1,189 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+func testConfig() Config {
+	return Config{
+		BucketSize:         time.Hour,
+		RunInterval:        time.Hour,
+		BaselineBuckets:    6,
+		SuccessRateDrop:    0.2,
+		RTTFactor:          2,
+		SustainedBuckets:   3,
+		FlapTransitions:    4,
+		HealthySuccessRate: 0.9,
+	}
+}
+
+// newSeries creates a series with one hourly point per success rate and RTT, the network is healthy and fast
+func newSeries(successRates, roundTripTimes []float64) *Series {
+	start := time.Date(2024, 9, 20, 0, 0, 0, 0, time.UTC)
+	series := &Series{
+		Orchestrator:     "orch1",
+		Region:           "FRA",
+		JobType:          models.AI.String(),
+		Pipeline:         "Text to image",
+		Model:            "model1",
+		NetworkMedianRTT: 1,
+	}
+	for i := range successRates {
+		series.Points = append(series.Points, &Point{
+			Start:              start.Add(time.Duration(i) * time.Hour),
+			SuccessRate:        successRates[i],
+			RoundTripTime:      roundTripTimes[i],
+			NetworkSuccessRate: 1,
+		})
+	}
+	return series
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name           string
+		successRates   []float64
+		roundTripTimes []float64
+		expected       [][2]string
+	}{
+		{
+			name:           "Stable orchestrator",
+			successRates:   []float64{1, 1, 0.95, 1, 1, 1, 1},
+			roundTripTimes: []float64{1, 1.1, 0.9, 1, 1, 1.2, 1},
+		},
+		{
+			name:           "Sudden drop in success rate",
+			successRates:   []float64{1, 1, 1, 1, 1, 1, 0.5},
+			roundTripTimes: []float64{1, 1, 1, 1, 1, 1, 1},
+			expected:       [][2]string{{models.AnomalySuddenDrop, models.AnomalyMetricSuccessRate}},
+		},
+		{
+			name:           "Sudden increase in round trip time",
+			successRates:   []float64{1, 1, 1, 1, 1, 1, 1},
+			roundTripTimes: []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 1.5},
+			expected:       [][2]string{{models.AnomalySuddenDrop, models.AnomalyMetricRoundTripTime}},
+		},
+		{
+			name:           "Too little history for a baseline",
+			successRates:   []float64{1, 1, 0.5},
+			roundTripTimes: []float64{1, 1, 1},
+		},
+		{
+			name:           "Sustained degradation against the network",
+			successRates:   []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5},
+			roundTripTimes: []float64{3, 3, 3, 3, 3, 3, 3},
+			expected: [][2]string{
+				{models.AnomalySustainedDegradation, models.AnomalyMetricSuccessRate},
+				{models.AnomalySustainedDegradation, models.AnomalyMetricRoundTripTime},
+			},
+		},
+		{
+			name:           "Flapping between healthy and unhealthy",
+			successRates:   []float64{1, 0.5, 1, 0.5, 1, 0.5, 1},
+			roundTripTimes: []float64{1, 1, 1, 1, 1, 1, 1},
+			expected:       [][2]string{{models.AnomalyFlapping, models.AnomalyMetricSuccessRate}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomalies := Detect(newSeries(tt.successRates, tt.roundTripTimes), testConfig())
+			if len(anomalies) != len(tt.expected) {
+				t.Fatalf("Expected %d anomalies, got %d: %+v", len(tt.expected), len(anomalies), anomalies)
+			}
+			for i, expected := range tt.expected {
+				if anomalies[i].Type != expected[0] || anomalies[i].Metric != expected[1] {
+					t.Errorf("Unexpected anomaly at position %d: got %v/%v want %v/%v", i, anomalies[i].Type, anomalies[i].Metric, expected[0], expected[1])
+				}
+				if anomalies[i].Orchestrator != "orch1" || anomalies[i].Region != "FRA" || anomalies[i].Pipeline != "Text to image" || anomalies[i].Model != "model1" {
+					t.Errorf("Anomaly is missing the series it was found in: %+v", anomalies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectWindows(t *testing.T) {
+	series := newSeries([]float64{1, 1, 1, 1, 1, 1, 0.5}, []float64{1, 1, 1, 1, 1, 1, 1})
+	latest := series.Points[len(series.Points)-1].Start
+
+	anomalies := Detect(series, testConfig())
+	if len(anomalies) != 1 {
+		t.Fatalf("Expected a single anomaly, got %+v", anomalies)
+	}
+	a := anomalies[0]
+	if a.WindowStart != latest.Unix() || a.WindowEnd != latest.Add(time.Hour).Unix() {
+		t.Errorf("Unexpected window %v - %v for the bucket starting at %v", a.WindowStart, a.WindowEnd, latest.Unix())
+	}
+	if a.Observed != 0.5 || a.Expected != 1 {
+		t.Errorf("Unexpected observed and expected values: %v, %v", a.Observed, a.Expected)
+	}
+}
+
+func TestDetectFlappingWindow(t *testing.T) {
+	series := newSeries([]float64{1, 0.5, 1, 0.5, 1, 0.5, 1}, []float64{1, 1, 1, 1, 1, 1, 1})
+	// the orchestrator was not tested during the first bucket of the baseline
+	series.Points = series.Points[1:]
+	latest := series.Points[len(series.Points)-1].Start
+
+	anomalies := Detect(series, testConfig())
+	if len(anomalies) != 1 || anomalies[0].Type != models.AnomalyFlapping {
+		t.Fatalf("Expected a single flapping anomaly, got %+v", anomalies)
+	}
+	// the window is aligned to the buckets checked rather than the first bucket with stats
+	a := anomalies[0]
+	if a.WindowStart != latest.Add(-6*time.Hour).Unix() || a.WindowEnd != latest.Add(time.Hour).Unix() {
+		t.Errorf("Unexpected window %v - %v for the bucket starting at %v", a.WindowStart, a.WindowEnd, latest.Unix())
+	}
+}
+
+func TestDetectSustainedRequiresConsecutiveBuckets(t *testing.T) {
+	series := newSeries([]float64{0.5, 0.5, 0.5}, []float64{1, 1, 1})
+	// a missing bucket in the middle of the window
+	series.Points[0].Start = series.Points[0].Start.Add(-time.Hour)
+
+	if anomalies := Detect(series, testConfig()); len(anomalies) != 0 {
+		t.Errorf("Expected no anomalies, got %+v", anomalies)
+	}
+}
+
+func TestCreateSeries(t *testing.T) {
+	start := time.Date(2024, 9, 20, 0, 0, 0, 0, time.UTC)
+	buckets := []*models.StatsBucket{
+		{
+			Start: start.Add(time.Hour),
+			Results: &models.AggregatedStatsResults{Stats: []*models.Stats{
+				{Orchestrator: "orch1", Region: "FRA", SuccessRate: 0.5, RoundTripTime: 2},
+				{Orchestrator: "orch2", Region: "FRA", SuccessRate: 1, RoundTripTime: 1},
+				{Orchestrator: "orch3", Region: "FRA", SuccessRate: 0.9, RoundTripTime: 1},
+			}},
+		},
+		{
+			Start: start,
+			Results: &models.AggregatedStatsResults{Stats: []*models.Stats{
+				{Orchestrator: "orch1", Region: "FRA", SuccessRate: 1, RoundTripTime: 1},
+			}},
+		},
+	}
+
+	series := createSeries(buckets, models.Transcoding.String(), 1)
+	if len(series) != 3 {
+		t.Fatalf("Expected 3 series, got %d", len(series))
+	}
+	orch1 := series[0]
+	if orch1.Orchestrator != "orch1" || len(orch1.Points) != 2 {
+		t.Fatalf("Unexpected first series: %+v", orch1)
+	}
+	if !orch1.Points[0].Start.Equal(start) || !orch1.Points[1].Start.Equal(start.Add(time.Hour)) {
+		t.Errorf("Points are not ordered by time: %v, %v", orch1.Points[0].Start, orch1.Points[1].Start)
+	}
+	if orch1.Points[1].NetworkSuccessRate != 0.9 {
+		t.Errorf("Expected the median success rate of the bucket, got %v", orch1.Points[1].NetworkSuccessRate)
+	}
+	if orch1.JobType != "transcoding" || orch1.NetworkMedianRTT != 1 {
+		t.Errorf("Unexpected job type or network median RTT: %+v", orch1)
+	}
+}
@@ -0,0 +1,152 @@
+package anomaly
+
+import (
+	"sort"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// Run checks every bucket completed during the run interval before now for every orchestrator, records the
+// anomalies found and returns them.  Anomalies recorded by an earlier run for the same window are returned but not
+// recorded again, so runs may overlap.
+func Run(now time.Time, config Config) ([]*models.Anomaly, error) {
+	checked := checkedBuckets(now, config)
+	end := checked[len(checked)-1].Add(config.BucketSize)
+	since := checked[0].Add(-time.Duration(config.BaselineBuckets) * config.BucketSize)
+	// event times are stored with microsecond precision so this excludes the bucket that is still in progress
+	until := end.Add(-time.Microsecond)
+
+	queries := []*models.StatsQuery{{Since: since, Until: until, JobType: models.Transcoding}}
+	pipelines, err := db.Store.Pipelines(&models.StatsQuery{Since: since, Until: until})
+	if err != nil {
+		return nil, err
+	}
+	for _, pipeline := range pipelines {
+		for _, model := range pipeline.Models {
+			queries = append(queries, &models.StatsQuery{Since: since, Until: until, JobType: models.AI, Pipelines: []string{pipeline.Name}, Models: []string{model}})
+		}
+	}
+
+	anomalies := []*models.Anomaly{}
+	for _, query := range queries {
+		found, err := detectQuery(query, checked, config)
+		if err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, found...)
+	}
+
+	inserted, err := db.Store.InsertAnomalies(anomalies)
+	if err != nil {
+		return nil, err
+	}
+	common.Logger.Info("Detected %d anomalies in the %d buckets starting from %v, %d of them new", len(anomalies), len(checked), checked[0], inserted)
+	return anomalies, nil
+}
+
+// checkedBuckets returns the start of every bucket that completed during the run interval before now, oldest
+// first.  The last complete bucket is always checked.
+func checkedBuckets(now time.Time, config Config) []time.Time {
+	end := now.UTC().Truncate(config.BucketSize)
+	previousRun := now.UTC().Add(-config.RunInterval)
+	checked := []time.Time{end.Add(-config.BucketSize)}
+	for start := checked[0].Add(-config.BucketSize); start.Add(config.BucketSize).After(previousRun); start = start.Add(-config.BucketSize) {
+		checked = append([]time.Time{start}, checked...)
+	}
+	return checked
+}
+
+// detectQuery runs the detector on every orchestrator and region with stats in each of the checked buckets of the
+// query.  Each bucket is compared with the baseline before it, the same as when it was the latest bucket.
+func detectQuery(query *models.StatsQuery, checked []time.Time, config Config) ([]*models.Anomaly, error) {
+	buckets, err := db.Store.StatsHistory(query, config.BucketSize)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies := []*models.Anomaly{}
+	for _, latest := range checked {
+		// the sustained window is compared with the network median RTT over the same window
+		medianQuery := *query
+		medianQuery.Since = latest.Add(-time.Duration(config.SustainedBuckets-1) * config.BucketSize)
+		medianQuery.Until = latest.Add(config.BucketSize - time.Microsecond)
+		networkMedianRTT, err := db.Store.MedianRTT(&medianQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		baselineStart := latest.Add(-time.Duration(config.BaselineBuckets) * config.BucketSize)
+		window := []*models.StatsBucket{}
+		for _, bucket := range buckets {
+			if !bucket.Start.Before(baselineStart) && !bucket.Start.After(latest) {
+				window = append(window, bucket)
+			}
+		}
+
+		for _, s := range createSeries(window, query.JobType.String(), networkMedianRTT) {
+			if last := s.Points[len(s.Points)-1]; !last.Start.Equal(latest) {
+				continue
+			}
+			anomalies = append(anomalies, Detect(s, config)...)
+		}
+	}
+	return anomalies, nil
+}
+
+// createSeries turns the stats buckets into one series per orchestrator, region and pipeline/model ordered by time.
+// The network success rate of a bucket is the median success rate of the orchestrators tested during it.
+func createSeries(buckets []*models.StatsBucket, jobType string, networkMedianRTT float64) []*Series {
+	series := []*Series{}
+	seriesByKey := make(map[[4]string]*Series)
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start.Before(buckets[j].Start)
+	})
+	for _, bucket := range buckets {
+		successRates := []float64{}
+		for _, stat := range bucket.Results.Stats {
+			successRates = append(successRates, stat.SuccessRate)
+		}
+		networkSuccessRate := median(successRates)
+
+		for _, stat := range bucket.Results.Stats {
+			key := [4]string{stat.Orchestrator, stat.Region, stat.Pipeline, stat.Model}
+			s, ok := seriesByKey[key]
+			if !ok {
+				s = &Series{
+					Orchestrator:     stat.Orchestrator,
+					Region:           stat.Region,
+					JobType:          jobType,
+					Pipeline:         stat.Pipeline,
+					Model:            stat.Model,
+					NetworkMedianRTT: networkMedianRTT,
+				}
+				seriesByKey[key] = s
+				series = append(series, s)
+			}
+			s.Points = append(s.Points, &Point{
+				Start:              bucket.Start,
+				SuccessRate:        stat.SuccessRate,
+				RoundTripTime:      stat.RoundTripTime,
+				NetworkSuccessRate: networkSuccessRate,
+			})
+		}
+	}
+	return series
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,51 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckedBuckets(t *testing.T) {
+	now := time.Date(2024, 9, 20, 10, 5, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 9, 20, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		bucketSize  time.Duration
+		runInterval time.Duration
+		expected    []time.Time
+	}{
+		{
+			name:        "bucket of the run interval",
+			bucketSize:  time.Hour,
+			runInterval: time.Hour,
+			expected:    []time.Time{at(9, 0)},
+		},
+		{
+			name:        "buckets shorter than the run interval",
+			bucketSize:  15 * time.Minute,
+			runInterval: time.Hour,
+			expected:    []time.Time{at(9, 0), at(9, 15), at(9, 30), at(9, 45)},
+		},
+		{
+			name:        "bucket longer than the run interval",
+			bucketSize:  2 * time.Hour,
+			runInterval: time.Hour,
+			expected:    []time.Time{at(8, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.BucketSize, config.RunInterval = tt.bucketSize, tt.runInterval
+			if diff := cmp.Diff(tt.expected, checkedBuckets(now, config)); diff != "" {
+				t.Errorf("Unexpected checked buckets (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/livepeer/leaderboard-serverless/common"
 	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/export"
 	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
 	"github.com/livepeer/leaderboard-serverless/models"
 	"github.com/livepeer/leaderboard-serverless/score"
 )
@@ -20,19 +22,33 @@ func AggregatedStatsHandler(w http.ResponseWriter, r *http.Request) {
 
 	middleware.AddStandardHttpHeaders(w)
 
+	if err := validation.ValidateQuery(r, "/api/aggregated_stats"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
 	statsQuery, err := common.ParseStatsQueryParams(r)
 	if err != nil {
 		common.HandleBadRequest(w, err)
 		return
 	}
 
+	format, err := common.ParseFormatParam(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	if err := statsQuery.CanScore(); err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
 	// since we need to get the median RTT for all Orchs
-	// we will check if a specific orch was requested
+	// we will check if specific orchs were requested
 	// and filter them out after we get the aggregated stats
-	orchestrator := statsQuery.Orchestrator
-	if orchestrator != "" {
-		statsQuery.Orchestrator = ""
-	}
+	orchestrators := statsQuery.Orchestrators
+	statsQuery.Orchestrators = nil
 
 	aggrStatResult, err := db.Store.AggregatedStats(statsQuery)
 	if err != nil {
@@ -42,16 +58,36 @@ func AggregatedStatsHandler(w http.ResponseWriter, r *http.Request) {
 
 	results := score.CreateAggregatedStats(aggrStatResult)
 
-	// if a specific orchestrator was requested, filter out the rest
-	if orchestrator != "" {
-		orchStats, ok := results[orchestrator]
-		if !ok {
-			results = make(map[string]map[string]*models.AggregatedStats)
-		} else {
-			results = map[string]map[string]*models.AggregatedStats{
-				orchestrator: orchStats,
+	errorCounts, err := db.Store.ErrorCounts(statsQuery)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	score.AddErrorCounts(results, errorCounts)
+
+	// if specific orchestrators were requested, filter out the rest
+	if len(orchestrators) > 0 {
+		orchResults := make(map[string]map[string]*models.AggregatedStats)
+		for _, orchestrator := range orchestrators {
+			if orchStats, ok := results[orchestrator]; ok {
+				orchResults[orchestrator] = orchStats
 			}
 		}
+		results = orchResults
+	}
+
+	if format != common.FormatJSON {
+		writer := export.NewWriter(w, format, "aggregated_stats", export.AggregatedStatsHeader)
+		for _, record := range export.AggregatedStatsRecords(results) {
+			if err := writer.Write(record); err != nil {
+				common.Logger.Error("Failed to export aggregated stats: %v", err)
+				return
+			}
+		}
+		if err := writer.Close(); err != nil {
+			common.Logger.Error("Failed to export aggregated stats: %v", err)
+		}
+		return
 	}
 
 	resultsEncoded, err := json.Marshal(results)
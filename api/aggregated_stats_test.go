@@ -27,16 +27,35 @@ func TestAggregatedStatsHandler(t *testing.T) {
 	testStats := testutils.GetTranscodingStats()
 	aiTestStats := testutils.GetBestAIStats()
 
-	//create the aggregated stats from the test data and compare
-	testTranscodingStatsArray := []*models.Stats{&testStats}
+	//create the aggregated stats from the test data and compare, including the timing breakdown of the single test
+	testStatsAggregated := testStats
+	testStatsAggregated.Tests = 1
+	testStatsAggregated.Timing = &models.TranscodingTiming{
+		SegDuration:          testStats.SegDuration,
+		RoundTripTime:        testStats.RoundTripTime,
+		UploadTime:           testStats.UploadTime,
+		DownloadTime:         testStats.DownloadTime,
+		TranscodeTime:        testStats.TranscodeTime,
+		SegmentsSent:         testStats.SegmentsSent,
+		SegmentsReceived:     testStats.SegmentsReceived,
+		SegmentDeliveryRatio: float64(testStats.SegmentsReceived) / float64(testStats.SegmentsSent),
+	}
+	testTranscodingStatsArray := []*models.Stats{&testStatsAggregated}
 	testTranscodingStatsResults := &models.AggregatedStatsResults{Stats: testTranscodingStatsArray}
 	testTranscodingAggregatedStats := score.CreateAggregatedStats(testTranscodingStatsResults)
 
-	//create the AI aggregated stats from the test data and compare
-	testAIStatsArray := []*models.Stats{&aiTestStats}
-	testAIStatsResults := &models.AggregatedStatsResults{Stats: testAIStatsArray, MedianRTT: 0.1}
+	//create the AI aggregated stats from the test data and compare, the test ran against a cold model
+	aiTestStatsAggregated := aiTestStats
+	aiTestStatsAggregated.Tests = 1
+	aiTestStatsAggregated.Cold = &models.ModelStateStats{SuccessRate: aiTestStats.SuccessRate, RoundTripTime: aiTestStats.RoundTripTime, Tests: 1}
+	testAIStatsArray := []*models.Stats{&aiTestStatsAggregated}
+	testAIStatsResults := &models.AggregatedStatsResults{Stats: testAIStatsArray, MedianRTT: 0.1, ColdMedianRTT: 0.1}
 	testAIAggregatedStats := score.CreateAggregatedStats(testAIStatsResults)
 
+	// the handler scores with the scoring configurations activated by the migrations
+	setScoreVersion(testTranscodingAggregatedStats, 2)
+	setScoreVersion(testAIAggregatedStats, 1)
+
 	// create an array with testStats and aiTestStats
 	allStatsArray := []*models.Stats{&testStats, &aiTestStats}
 
@@ -61,6 +80,12 @@ func TestAggregatedStatsHandler(t *testing.T) {
 			expectedStatus:    http.StatusOK,
 			expectedAggrStats: testAIAggregatedStats,
 		},
+		{
+			name:              "Test with fewer tests than the minimum number of samples",
+			queryParams:       "until=" + until + "&since=" + since + "&min_samples=2",
+			expectedStatus:    http.StatusOK,
+			expectedAggrStats: map[string]map[string]*models.AggregatedStats{},
+		},
 		{
 			name:              "Test with invalid input and query params",
 			queryParams:       "until=" + strconv.FormatInt(time.Now().AddDate(-1, 0, 0).Unix(), 10), // One year ago
@@ -79,10 +104,29 @@ func TestAggregatedStatsHandler(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedError:  `{"error":"model required"}`,
 		},
+		{
+			name:           "Test with invalid query params - unknown job type",
+			queryParams:    "job_type=video",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `{"error":"request validation failed","details":[{"in":"query","field":"job_type","message":"must be one of 'ai', 'transcoding'"}]}`,
+		},
 	}
 	runTests(t, tests, allStatsArray)
 }
 
+// setScoreVersion sets the scoring configuration version of the aggregated stats and their model states
+func setScoreVersion(results map[string]map[string]*models.AggregatedStats, version int64) {
+	for _, regions := range results {
+		for _, aggrStats := range regions {
+			for _, stats := range []*models.AggregatedStats{aggrStats, aggrStats.Warm, aggrStats.Cold} {
+				if stats != nil {
+					stats.ScoreVersion = version
+				}
+			}
+		}
+	}
+}
+
 func runTests(t *testing.T, tests []struct {
 	name              string
 	queryParams       string
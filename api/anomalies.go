@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// AnomaliesHandler handles a request for the anomalies recorded by the detector
+func AnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/anomalies"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	// unlike the stats, anomalies can be filtered by pipeline without a model
+	params := r.URL.Query()
+	pipelines, aiModels := common.ParseListParam(params, "pipeline", nil), common.ParseListParam(params, "model", nil)
+	params.Del("pipeline")
+	params.Del("model")
+	statsRequest := r.Clone(r.Context())
+	statsRequest.URL.RawQuery = params.Encode()
+
+	statsQuery, err := common.ParseStatsQueryParams(statsRequest)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+	statsQuery.Pipelines = pipelines
+	statsQuery.Models = aiModels
+	page, err := common.ParsePageQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+	statsQuery.Limit = page.Limit
+
+	anomalyType := strings.ToLower(r.URL.Query().Get("type"))
+	if anomalyType != "" && !models.IsValidAnomalyType(anomalyType) {
+		common.HandleBadRequest(w, models.ErrInvalidAnomalyType)
+		return
+	}
+
+	anomalies, err := db.Store.Anomalies(statsQuery, anomalyType)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	resultsEncoded, err := json.Marshal(map[string][]*models.Anomaly{"anomalies": anomalies})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
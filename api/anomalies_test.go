@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestAnomaliesHandler(t *testing.T) {
+
+	// setup data
+	windowEnd := time.Now().UTC().Truncate(time.Hour)
+	windowStart := windowEnd.Add(-time.Hour)
+	suddenDrop := &models.Anomaly{
+		Type:         models.AnomalySuddenDrop,
+		Metric:       models.AnomalyMetricSuccessRate,
+		Orchestrator: testutils.GetOrchestratorID(),
+		Region:       "FRA",
+		JobType:      models.AI.String(),
+		Pipeline:     testutils.GetPipeline(),
+		Model:        testutils.GetModel(),
+		Observed:     0.5,
+		Expected:     1,
+		WindowStart:  windowStart.Unix(),
+		WindowEnd:    windowEnd.Unix(),
+	}
+	flapping := &models.Anomaly{
+		Type:         models.AnomalyFlapping,
+		Metric:       models.AnomalyMetricSuccessRate,
+		Orchestrator: "orch2",
+		Region:       "LAX",
+		JobType:      models.Transcoding.String(),
+		Observed:     5,
+		Expected:     4,
+		WindowStart:  windowStart.Add(-time.Hour).Unix(),
+		WindowEnd:    windowEnd.Add(-time.Hour).Unix(),
+	}
+	// flapping found again by the next run overlaps the flapping already recorded
+	stillFlapping := *flapping
+	stillFlapping.WindowStart = windowStart.Unix()
+	stillFlapping.WindowEnd = windowEnd.Unix()
+	// the same anomaly found by a second run is only recorded once
+	anomaliesToInsert := []*models.Anomaly{suddenDrop, flapping, suddenDrop, &stillFlapping}
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedTypes  []string
+	}{
+		{
+			name:           "All anomalies, most recent first",
+			expectedStatus: http.StatusOK,
+			expectedTypes:  []string{models.AnomalySuddenDrop, models.AnomalyFlapping},
+		},
+		{
+			name:           "Filtered by orchestrator",
+			queryParams:    "orchestrator=orch2",
+			expectedStatus: http.StatusOK,
+			expectedTypes:  []string{models.AnomalyFlapping},
+		},
+		{
+			name:           "Filtered by region",
+			queryParams:    "region=fra",
+			expectedStatus: http.StatusOK,
+			expectedTypes:  []string{models.AnomalySuddenDrop},
+		},
+		{
+			name:           "Filtered by pipeline without a model",
+			queryParams:    "pipeline=" + url.QueryEscape(testutils.GetPipeline()),
+			expectedStatus: http.StatusOK,
+			expectedTypes:  []string{models.AnomalySuddenDrop},
+		},
+		{
+			name:           "Filtered by type",
+			queryParams:    "type=flapping",
+			expectedStatus: http.StatusOK,
+			expectedTypes:  []string{models.AnomalyFlapping},
+		},
+		{
+			name:           "Limited",
+			queryParams:    "limit=1",
+			expectedStatus: http.StatusOK,
+			expectedTypes:  []string{models.AnomalySuddenDrop},
+		},
+		{
+			name:           "Invalid type",
+			queryParams:    "type=outage",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+			inserted, err := db.Store.InsertAnomalies(anomaliesToInsert)
+			if err != nil {
+				t.Fatalf("Unexpected error when inserting anomalies: %v", err)
+			}
+			if inserted != 2 {
+				t.Fatalf("Expected 2 anomalies to be inserted, got %d", inserted)
+			}
+
+			req, err := http.NewRequest("GET", "/api/anomalies?"+tt.queryParams, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(AnomaliesHandler)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var responseBody map[string][]*models.Anomaly
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseBody); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			anomalies := responseBody["anomalies"]
+			if len(anomalies) != len(tt.expectedTypes) {
+				t.Fatalf("Handler returned unexpected anomalies: got %v", rr.Body.String())
+			}
+			for i, expectedType := range tt.expectedTypes {
+				got := anomalies[i]
+				if got.Type != expectedType {
+					t.Errorf("Unexpected anomaly at position %d: got %v want %v", i, got.Type, expectedType)
+				}
+				if got.ID == 0 || got.DetectedAt == 0 {
+					t.Errorf("Expected the id and detection time of the anomaly: %+v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectAnomaliesHandlerAuthorization(t *testing.T) {
+	testutils.NewDB(t)
+
+	tests := []struct {
+		name           string
+		secret         string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "No secret configured", authorization: "Bearer ", expectedStatus: http.StatusForbidden},
+		{name: "Wrong secret", secret: "secret", authorization: "Bearer wrong", expectedStatus: http.StatusForbidden},
+		{name: "Valid secret", secret: "secret", authorization: "Bearer secret", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CRON_SECRET", tt.secret)
+
+			req, err := http.NewRequest("GET", "/api/detect_anomalies", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", tt.authorization)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(DetectAnomaliesHandler)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+		})
+	}
+}
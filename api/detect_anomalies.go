@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/anomaly"
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/auth"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// DetectAnomaliesHandler runs the anomaly detector over every bucket completed since the previous run.  It is called by the
+// Vercel cron job, which authenticates with the CRON_SECRET as a bearer token.
+func DetectAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if !auth.IsCronAuthorized(r.Header.Get("Authorization")) {
+		common.RespondWithError(w, errors.New("request can not be authenticated"), http.StatusForbidden)
+		return
+	}
+
+	anomalies, err := anomaly.Run(time.Now(), anomaly.DefaultConfig())
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	resultsEncoded, err := json.Marshal(map[string][]*models.Anomaly{"anomalies": anomalies})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
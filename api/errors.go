@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// ErrorsHandler handles a request for the error counts by error code
+func ErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/errors"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	statsQuery, err := common.ParseStatsQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	errorCounts, err := db.Store.ErrorCounts(statsQuery)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	resultsEncoded, err := json.Marshal(map[string][]*models.ErrorCount{"errors": errorCounts})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestErrorsHandler(t *testing.T) {
+
+	// setup data
+	testStats := testutils.GetTranscodingStats()
+
+	aiTestFailingStats := testutils.GetAIStats()
+
+	aiTestTimeoutStats := testutils.GetAIStats()
+	aiTestTimeoutStats.Errors = []models.Error{
+		{ErrorCode: "timeout", Count: 2},
+		{ErrorCode: "HTTP-STATUS-503", Count: 1},
+	}
+
+	aiTestBestStats := testutils.GetBestAIStats()
+
+	aiParams := "pipeline=" + url.QueryEscape(aiTestFailingStats.Pipeline) + "&model=" + url.QueryEscape(aiTestFailingStats.Model) +
+		"&since=" + testutils.GetUnixTimeMinusTenSecStr() + "&until=" + testutils.GetUnixTimeInFiveSecStr()
+
+	// test cases
+	tests := []struct {
+		name                    string
+		queryParams             string
+		expectedStatus          int
+		expectedBody            string
+		statsToInsertBeforeTest []*models.Stats
+	}{
+		{
+			name:                    "Error counts for AI",
+			queryParams:             aiParams,
+			statsToInsertBeforeTest: []*models.Stats{&aiTestFailingStats, &aiTestTimeoutStats, &aiTestBestStats},
+			expectedStatus:          http.StatusOK,
+			expectedBody: fmt.Sprintf(`{"errors":[{"orchestrator":"%[1]s","region":"%[2]s","pipeline":"%[3]s","model":"%[4]s","error_code":"HTTP-STATUS-503","count":2},{"orchestrator":"%[1]s","region":"%[2]s","pipeline":"%[3]s","model":"%[4]s","error_code":"timeout","count":2}]}`,
+				aiTestFailingStats.Orchestrator, aiTestFailingStats.Region, aiTestFailingStats.Pipeline, aiTestFailingStats.Model),
+		},
+		{
+			name:                    "No errors for transcoding",
+			queryParams:             "since=" + testutils.GetUnixTimeMinusTenSecStr(),
+			statsToInsertBeforeTest: []*models.Stats{&testStats, &aiTestFailingStats},
+			expectedStatus:          http.StatusOK,
+			expectedBody:            `{"errors":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+
+			// insert the stats before the test
+			for _, stats := range tt.statsToInsertBeforeTest {
+				if err := db.Store.InsertStats(stats); err != nil {
+					t.Fatalf("Unexpected error when inserting stats: %v", err)
+				}
+			}
+
+			// Create a new HTTP request with query parameters
+			req, err := http.NewRequest("GET", "/errors?"+tt.queryParams, bytes.NewBuffer([]byte{}))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			// Create a ResponseRecorder to record the response
+			rr := httptest.NewRecorder()
+
+			// Call the handler
+			handler := http.HandlerFunc(ErrorsHandler)
+			handler.ServeHTTP(rr, req)
+
+			// Check the status code
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+
+			// Compare the response body with the expected body
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Handler returned unexpected body: got %v want %v", rr.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/auth"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/webhook"
+)
+
+// EvaluateWebhooksHandler evaluates every webhook and sends the notifications and retries that are due.  It is
+// called by the Vercel cron job, which authenticates with the CRON_SECRET as a bearer token.
+func EvaluateWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if !auth.IsCronAuthorized(r.Header.Get("Authorization")) {
+		common.RespondWithError(w, errors.New("request can not be authenticated"), http.StatusForbidden)
+		return
+	}
+
+	deliveries, err := webhook.Evaluate(time.Now().UTC())
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	resultsEncoded, err := json.Marshal(map[string][]*models.WebhookDelivery{"deliveries": deliveries})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
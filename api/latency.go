@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// LatencyHandler handles a request for the RTT percentiles of the network and of each orchestrator
+func LatencyHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/latency"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	statsQuery, err := common.ParseStatsQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	orchestrators, err := db.Store.LatencyPercentiles(statsQuery)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	// the network percentiles always include every orchestrator
+	networkQuery := *statsQuery
+	networkQuery.Orchestrators = nil
+	network, err := db.Store.NetworkLatencyPercentiles(&networkQuery)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	resultsEncoded, err := json.Marshal(models.LatencyReport{
+		Network:       network,
+		Orchestrators: orchestrators,
+	})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
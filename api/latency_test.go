@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestLatencyHandler(t *testing.T) {
+
+	// setup data
+	statsToInsert := []*models.Stats{}
+	for _, rtt := range []float64{1, 2, 3, 4} {
+		stats := testutils.GetBestAIStats()
+		stats.RoundTripTime = rtt
+		statsToInsert = append(statsToInsert, &stats)
+	}
+	secondOrchStats := testutils.GetBestAIStats()
+	secondOrchStats.Orchestrator = "orch2"
+	secondOrchStats.RoundTripTime = 10
+	statsToInsert = append(statsToInsert, &secondOrchStats)
+
+	// failed tests are not part of the percentiles
+	failingStats := testutils.GetAIStats()
+	failingStats.Region = secondOrchStats.Region
+	failingStats.RoundTripTime = 100
+	statsToInsert = append(statsToInsert, &failingStats)
+
+	aiParams := "pipeline=" + url.QueryEscape(secondOrchStats.Pipeline) + "&model=" + url.QueryEscape(secondOrchStats.Model)
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedReport models.LatencyReport
+	}{
+		{
+			name:           "Percentiles of an orchestrator and the network",
+			queryParams:    aiParams + "&orchestrator=" + testutils.GetOrchestratorID(),
+			expectedStatus: http.StatusOK,
+			expectedReport: models.LatencyReport{
+				Network: &models.LatencyPercentiles{P50: 3, P90: 7.6, P95: 8.8, P99: 9.76, Samples: 5},
+				Orchestrators: []*models.OrchestratorLatency{
+					{
+						Orchestrator:       testutils.GetOrchestratorID(),
+						Region:             secondOrchStats.Region,
+						Pipeline:           secondOrchStats.Pipeline,
+						Model:              secondOrchStats.Model,
+						LatencyPercentiles: models.LatencyPercentiles{P50: 2.5, P90: 3.7, P95: 3.85, P99: 3.97, Samples: 4},
+					},
+				},
+			},
+		},
+		{
+			name:           "No transcoding stats",
+			queryParams:    "job_type=transcoding",
+			expectedStatus: http.StatusOK,
+			expectedReport: models.LatencyReport{
+				Network:       &models.LatencyPercentiles{},
+				Orchestrators: []*models.OrchestratorLatency{},
+			},
+		},
+		{
+			name:           "Invalid job type",
+			queryParams:    "job_type=video",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+			for _, stats := range statsToInsert {
+				if err := db.Store.InsertStats(stats); err != nil {
+					t.Fatalf("Unexpected error when inserting stats: %v", err)
+				}
+			}
+
+			req, err := http.NewRequest("GET", "/api/latency?"+tt.queryParams, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(LatencyHandler)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var report models.LatencyReport
+			if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			assertLatencyPercentiles(t, "network", report.Network, tt.expectedReport.Network)
+			if len(report.Orchestrators) != len(tt.expectedReport.Orchestrators) {
+				t.Fatalf("Handler returned unexpected orchestrators: got %v", rr.Body.String())
+			}
+			for i, expected := range tt.expectedReport.Orchestrators {
+				got := report.Orchestrators[i]
+				if got.Orchestrator != expected.Orchestrator || got.Region != expected.Region || got.Pipeline != expected.Pipeline || got.Model != expected.Model {
+					t.Errorf("Unexpected orchestrator at position %d: got %+v want %+v", i, got, expected)
+				}
+				assertLatencyPercentiles(t, got.Orchestrator, &got.LatencyPercentiles, &expected.LatencyPercentiles)
+			}
+		})
+	}
+}
+
+func assertLatencyPercentiles(t *testing.T, name string, got, expected *models.LatencyPercentiles) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("Missing %s percentiles", name)
+	}
+	approxEqual := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if !approxEqual(got.P50, expected.P50) || !approxEqual(got.P90, expected.P90) || !approxEqual(got.P95, expected.P95) || !approxEqual(got.P99, expected.P99) || got.Samples != expected.Samples {
+		t.Errorf("Unexpected %s percentiles: got %+v want %+v", name, got, expected)
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+)
+
+// LeaderboardHandler handles a request for the ranked and paginated leaderboard
+func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/leaderboard"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	statsQuery, err := common.ParseStatsQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+	pageQuery, err := common.ParsePageQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+	sortField, err := common.ParseLeaderboardSortParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	if err := statsQuery.CanScore(); err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	// the ranking is computed across all orchestrators, so specific
+	// orchestrators are filtered out after the leaderboard is created
+	orchestrators := statsQuery.Orchestrators
+	statsQuery.Orchestrators = nil
+
+	aggrStatResult, err := db.Store.AggregatedStats(statsQuery)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	entries := score.CreateLeaderboard(aggrStatResult, sortField)
+
+	// rank the preceding window of equal length the same way to show how each entry moved
+	window := statsQuery.Until.Sub(statsQuery.Since)
+	previousQuery := *statsQuery
+	previousQuery.Until = statsQuery.Since
+	previousQuery.Since = statsQuery.Since.Add(-window)
+	previousAggrStatResult, err := db.Store.AggregatedStats(&previousQuery)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	score.AddRankMovement(entries, score.CreateLeaderboard(previousAggrStatResult, sortField))
+
+	// if specific orchestrators were requested, filter out the rest
+	// while keeping the rank they hold on the full leaderboard
+	if len(orchestrators) > 0 {
+		orchEntries := []*models.LeaderboardEntry{}
+		for _, entry := range entries {
+			if slices.Contains(orchestrators, entry.Orchestrator) {
+				orchEntries = append(orchEntries, entry)
+			}
+		}
+		entries = orchEntries
+	}
+
+	leaderboard := models.Leaderboard{
+		Total:   len(entries),
+		Limit:   pageQuery.Limit,
+		Offset:  pageQuery.Offset,
+		Entries: []*models.LeaderboardEntry{},
+	}
+	if pageQuery.Offset < len(entries) {
+		end := min(pageQuery.Offset+pageQuery.Limit, len(entries))
+		leaderboard.Entries = entries[pageQuery.Offset:end]
+	}
+
+	resultsEncoded, err := json.Marshal(leaderboard)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	common.Logger.Trace("Returning leaderboard: %s", resultsEncoded)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestLeaderboardHandler(t *testing.T) {
+
+	// setup data
+	aiTestBestStats := testutils.GetBestAIStats()
+
+	aiTestSlowStats := testutils.GetBestAIStats()
+	aiTestSlowStats.Orchestrator = "orch2"
+	aiTestSlowStats.RoundTripTime = 4.2
+
+	aiTestFailingStats := testutils.GetBestAIStats()
+	aiTestFailingStats.Orchestrator = "orch3"
+	aiTestFailingStats.SuccessRate = 0
+
+	// same results as orch2, so the tie must be broken by orchestrator
+	aiTestTiedStats := aiTestSlowStats
+	aiTestTiedStats.Orchestrator = "orch1"
+
+	allStats := []*models.Stats{&aiTestFailingStats, &aiTestSlowStats, &aiTestBestStats, &aiTestTiedStats}
+	aiParams := "pipeline=" + url.QueryEscape(aiTestBestStats.Pipeline) + "&model=" + url.QueryEscape(aiTestBestStats.Model) +
+		"&since=" + testutils.GetUnixTimeMinusTenSecStr() + "&until=" + testutils.GetUnixTimeInFiveSecStr()
+
+	// test cases
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedTotal  int
+		expectedOrchs  []string
+		expectedRanks  []int
+	}{
+		{
+			name:           "Ranked by score",
+			queryParams:    aiParams,
+			expectedStatus: http.StatusOK,
+			expectedTotal:  4,
+			expectedOrchs:  []string{aiTestBestStats.Orchestrator, "orch1", "orch2", "orch3"},
+			expectedRanks:  []int{1, 2, 3, 4},
+		},
+		{
+			name:           "Ranked by score ascending",
+			queryParams:    aiParams + "&order=asc",
+			expectedStatus: http.StatusOK,
+			expectedTotal:  4,
+			expectedOrchs:  []string{"orch3", "orch1", "orch2", aiTestBestStats.Orchestrator},
+			expectedRanks:  []int{1, 2, 3, 4},
+		},
+		{
+			name:           "Paginated",
+			queryParams:    aiParams + "&limit=2&offset=1",
+			expectedStatus: http.StatusOK,
+			expectedTotal:  4,
+			expectedOrchs:  []string{"orch1", "orch2"},
+			expectedRanks:  []int{2, 3},
+		},
+		{
+			name:           "Single orchestrator keeps its rank",
+			queryParams:    aiParams + "&orchestrator=orch2",
+			expectedStatus: http.StatusOK,
+			expectedTotal:  1,
+			expectedOrchs:  []string{"orch2"},
+			expectedRanks:  []int{3},
+		},
+		{
+			name:           "Offset past the end",
+			queryParams:    aiParams + "&offset=10",
+			expectedStatus: http.StatusOK,
+			expectedTotal:  4,
+			expectedOrchs:  []string{},
+			expectedRanks:  []int{},
+		},
+		{
+			name:           "Invalid sort",
+			queryParams:    aiParams + "&sort=bogus",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid limit",
+			queryParams:    aiParams + "&limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+
+			// insert the stats before the test
+			for _, stats := range allStats {
+				if err := db.Store.InsertStats(stats); err != nil {
+					t.Fatalf("Unexpected error when inserting stats: %v", err)
+				}
+			}
+
+			// Create a new HTTP request with query parameters
+			req, err := http.NewRequest("GET", "/leaderboard?"+tt.queryParams, bytes.NewBuffer([]byte{}))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			// Create a ResponseRecorder to record the response
+			rr := httptest.NewRecorder()
+
+			// Call the handler
+			handler := http.HandlerFunc(LeaderboardHandler)
+			handler.ServeHTTP(rr, req)
+
+			// Check the status code
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var leaderboard models.Leaderboard
+			if err := json.Unmarshal(rr.Body.Bytes(), &leaderboard); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+
+			if leaderboard.Total != tt.expectedTotal {
+				t.Errorf("Handler returned unexpected total: got %v want %v", leaderboard.Total, tt.expectedTotal)
+			}
+			if len(leaderboard.Entries) != len(tt.expectedOrchs) {
+				t.Fatalf("Handler returned unexpected number of entries: got %v want %v", len(leaderboard.Entries), len(tt.expectedOrchs))
+			}
+			for i, entry := range leaderboard.Entries {
+				if entry.Orchestrator != tt.expectedOrchs[i] || entry.Rank != tt.expectedRanks[i] {
+					t.Errorf("Handler returned unexpected entry at position %d: got %v (rank %d) want %v (rank %d)",
+						i, entry.Orchestrator, entry.Rank, tt.expectedOrchs[i], tt.expectedRanks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLeaderboardHandlerRankMovement(t *testing.T) {
+	testutils.NewDB(t)
+
+	previousWindow := time.Now().Add(-20 * time.Second).Unix()
+
+	// orch2 led the previous window and slowed down, the best orchestrator recovered its success rate
+	previousBestStats := testutils.GetBestAIStats()
+	previousBestStats.SuccessRate = 0.5
+	previousBestStats.Timestamp = previousWindow
+	previousSlowStats := testutils.GetBestAIStats()
+	previousSlowStats.Orchestrator = "orch2"
+	previousSlowStats.Timestamp = previousWindow
+
+	currentBestStats := testutils.GetBestAIStats()
+	currentSlowStats := testutils.GetBestAIStats()
+	currentSlowStats.Orchestrator = "orch2"
+	currentSlowStats.RoundTripTime = 4.2
+
+	// orch3 was not tested in the previous window
+	currentNewStats := testutils.GetBestAIStats()
+	currentNewStats.Orchestrator = "orch3"
+	currentNewStats.SuccessRate = 0
+
+	for _, stats := range []*models.Stats{&previousBestStats, &previousSlowStats, &currentBestStats, &currentSlowStats, &currentNewStats} {
+		if err := db.Store.InsertStats(stats); err != nil {
+			t.Fatalf("Unexpected error when inserting stats: %v", err)
+		}
+	}
+
+	queryParams := "pipeline=" + url.QueryEscape(currentBestStats.Pipeline) + "&model=" + url.QueryEscape(currentBestStats.Model) +
+		"&since=" + testutils.GetUnixTimeMinusTenSecStr() + "&until=" + testutils.GetUnixTimeInFiveSecStr()
+	req, err := http.NewRequest("GET", "/leaderboard?"+queryParams, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(LeaderboardHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var leaderboard models.Leaderboard
+	if err := json.Unmarshal(rr.Body.Bytes(), &leaderboard); err != nil {
+		t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+	}
+	if len(leaderboard.Entries) != 3 {
+		t.Fatalf("Handler returned unexpected number of entries: %s", rr.Body.String())
+	}
+
+	best, slow, newcomer := leaderboard.Entries[0], leaderboard.Entries[1], leaderboard.Entries[2]
+	if best.Orchestrator != currentBestStats.Orchestrator || slow.Orchestrator != "orch2" || newcomer.Orchestrator != "orch3" {
+		t.Fatalf("Handler returned unexpected ranking: %s", rr.Body.String())
+	}
+	if best.Movement == nil || best.Movement.PreviousRank != 2 || best.Movement.RankDelta != 1 || best.Movement.ScoreDelta <= 0 || best.Movement.Driver != score.MovementDriverSuccessRate {
+		t.Errorf("Unexpected movement of the best orchestrator: %+v", best.Movement)
+	}
+	if slow.Movement == nil || slow.Movement.PreviousRank != 1 || slow.Movement.RankDelta != -1 || slow.Movement.ScoreDelta >= 0 || slow.Movement.Driver != score.MovementDriverRoundTripScore {
+		t.Errorf("Unexpected movement of orch2: %+v", slow.Movement)
+	}
+	if newcomer.Movement != nil {
+		t.Errorf("Expected no movement for an orchestrator that was not ranked before, got %+v", newcomer.Movement)
+	}
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/livepeer/leaderboard-serverless/assets"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+)
+
+// OpenAPIHandler serves the OpenAPI specification of the API
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	middleware.AddStandardHttpHeaders(w)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(assets.GetOpenAPISpec())
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+)
+
+// orchestratorsPathPrefix is the path the orchestrator profiles are served from
+const orchestratorsPathPrefix = "/api/orchestrators/"
+
+// orchestratorHistoryDays is how many days of the history of an orchestrator its profile covers
+var orchestratorHistoryDays = common.EnvOrDefault("ORCHESTRATOR_HISTORY_DAYS", 30).(int)
+
+// OrchestratorHandler handles a request for the profile of a single orchestrator at /api/orchestrators/{address}.
+// The address is read from the 'address' parameter when the path was rewritten and from the path otherwise.
+func OrchestratorHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/orchestrators/{address}"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		address = strings.TrimPrefix(r.URL.Path, orchestratorsPathPrefix)
+	}
+	address = strings.ToLower(strings.Trim(address, "/"))
+	if address == "" || strings.Contains(address, "/") {
+		common.HandleBadRequest(w, errors.New("address is a required parameter"))
+		return
+	}
+
+	until := time.Now().UTC()
+	activity, err := db.Store.OrchestratorActivity(address, until.AddDate(0, 0, -orchestratorHistoryDays))
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	if len(activity) == 0 {
+		common.RespondWithError(w, models.ErrOrchestratorNotFound, http.StatusNotFound)
+		return
+	}
+
+	profile := newOrchestratorProfile(address, activity)
+
+	since := common.GetDefaultSince()
+	profile.Scores, err = orchestratorScores(address, activity, since, until)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	// recent errors of every job type the orchestrator was tested for
+	for _, jobTypeName := range profile.JobTypes {
+		jobType, err := models.JobTypeFromString(jobTypeName)
+		if err != nil {
+			continue
+		}
+		errorCounts, err := db.Store.ErrorCounts(&models.StatsQuery{
+			Orchestrators: []string{address},
+			Since:         since,
+			Until:         until,
+			JobType:       jobType,
+		})
+		if err != nil {
+			common.HandleInternalError(w, err)
+			return
+		}
+		profile.Errors = append(profile.Errors, errorCounts...)
+	}
+
+	resultsEncoded, err := json.Marshal(profile)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
+
+// newOrchestratorProfile summarises the activity of an orchestrator, which is sorted by job type, region, pipeline and model
+func newOrchestratorProfile(address string, activity []*models.OrchestratorActivity) *models.OrchestratorProfile {
+	profile := &models.OrchestratorProfile{
+		Address:   address,
+		JobTypes:  []string{},
+		Regions:   map[string][]string{},
+		Pipelines: []*models.Pipeline{},
+		Scores:    []*models.OrchestratorScore{},
+		Errors:    []*models.ErrorCount{},
+	}
+
+	var firstSeen, lastSeen time.Time
+	pipelines := map[string]*models.Pipeline{}
+	for _, a := range activity {
+		if firstSeen.IsZero() || a.FirstSeen.Before(firstSeen) {
+			firstSeen = a.FirstSeen
+		}
+		if a.LastSeen.After(lastSeen) {
+			lastSeen = a.LastSeen
+		}
+		profile.Tests += a.Tests
+
+		if n := len(profile.JobTypes); n == 0 || profile.JobTypes[n-1] != a.JobType {
+			profile.JobTypes = append(profile.JobTypes, a.JobType)
+		}
+		if regions := profile.Regions[a.JobType]; len(regions) == 0 || regions[len(regions)-1] != a.Region {
+			profile.Regions[a.JobType] = append(regions, a.Region)
+		}
+
+		if a.Pipeline == "" {
+			continue
+		}
+		pipeline, ok := pipelines[a.Pipeline]
+		if !ok {
+			pipeline = &models.Pipeline{Name: a.Pipeline, Models: []string{}, Regions: []string{}}
+			pipelines[a.Pipeline] = pipeline
+			profile.Pipelines = append(profile.Pipelines, pipeline)
+		}
+		pipeline.Models = appendUnique(pipeline.Models, a.Model)
+		pipeline.Regions = appendUnique(pipeline.Regions, a.Region)
+	}
+	profile.FirstSeen = firstSeen.Unix()
+	profile.LastSeen = lastSeen.Unix()
+
+	sort.Slice(profile.Pipelines, func(i, j int) bool {
+		return profile.Pipelines[i].Name < profile.Pipelines[j].Name
+	})
+	for _, pipeline := range profile.Pipelines {
+		sort.Strings(pipeline.Models)
+		sort.Strings(pipeline.Regions)
+	}
+	return profile
+}
+
+// orchestratorScores calculates the current score of the orchestrator in every region it was recently tested from.
+// Transcoding is scored once and every AI pipeline and model is scored against the median RTT of that pipeline and
+// model, the stats of every pipeline and model of a job type are fetched at once.
+func orchestratorScores(address string, activity []*models.OrchestratorActivity, since, until time.Time) ([]*models.OrchestratorScore, error) {
+	scores := []*models.OrchestratorScore{}
+
+	seen := map[string]bool{}
+	for _, a := range activity {
+		if a.LastSeen.Before(since) || seen[a.JobType] {
+			continue
+		}
+		seen[a.JobType] = true
+		jobType, err := models.JobTypeFromString(a.JobType)
+		if err != nil {
+			continue
+		}
+
+		aggrStatResults, err := db.Store.AggregatedStatsByModel(&models.StatsQuery{
+			Since:         since,
+			Until:         until,
+			Orchestrators: []string{address},
+			JobType:       jobType,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, aggrStatResult := range aggrStatResults {
+			results := score.CreateAggregatedStats(aggrStatResult)
+			for region, stats := range results[address] {
+				scores = append(scores, &models.OrchestratorScore{
+					JobType:        jobType.String(),
+					Region:         region,
+					Pipeline:       aggrStatResult.Stats[0].Pipeline,
+					Model:          aggrStatResult.Stats[0].Model,
+					SuccessRate:    stats.SuccessRate,
+					RoundTripScore: stats.RoundTripScore,
+					TotalScore:     stats.TotalScore,
+					ScoreVersion:   stats.ScoreVersion,
+				})
+			}
+		}
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		a, b := scores[i], scores[j]
+		if a.JobType != b.JobType {
+			return a.JobType < b.JobType
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.Pipeline != b.Pipeline {
+			return a.Pipeline < b.Pipeline
+		}
+		return a.Model < b.Model
+	})
+	return scores, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestOrchestratorHandler(t *testing.T) {
+
+	// setup data
+	transcodingStats := testutils.GetTranscodingStats()
+	aiTestFailingStats := testutils.GetAIStats()
+	aiTestBestStats := testutils.GetBestAIStats()
+	// tests older than the history the profile covers are left out
+	oldStats := testutils.GetTranscodingStats()
+	oldStats.Region = "SIN"
+	oldStats.Timestamp = time.Now().AddDate(0, 0, -orchestratorHistoryDays-1).Unix()
+	statsToInsert := []*models.Stats{&transcodingStats, &aiTestFailingStats, &aiTestBestStats, &oldStats}
+
+	orchId := testutils.GetOrchestratorID()
+	expectedProfile := &models.OrchestratorProfile{
+		Address:  orchId,
+		Tests:    3,
+		JobTypes: []string{"ai", "transcoding"},
+		Regions: map[string][]string{
+			"ai":          {aiTestBestStats.Region, aiTestFailingStats.Region},
+			"transcoding": {transcodingStats.Region},
+		},
+		Pipelines: []*models.Pipeline{
+			{Name: aiTestBestStats.Pipeline, Models: []string{aiTestBestStats.Model}, Regions: []string{aiTestBestStats.Region, aiTestFailingStats.Region}},
+		},
+		Scores: []*models.OrchestratorScore{
+			{JobType: "ai", Region: aiTestBestStats.Region, Pipeline: aiTestBestStats.Pipeline, Model: aiTestBestStats.Model, SuccessRate: 1, RoundTripScore: 0.8, TotalScore: 0.93, ScoreVersion: 1},
+			{JobType: "ai", Region: aiTestFailingStats.Region, Pipeline: aiTestFailingStats.Pipeline, Model: aiTestFailingStats.Model, ScoreVersion: 1},
+			{JobType: "transcoding", Region: transcodingStats.Region, SuccessRate: 1, ScoreVersion: 2},
+		},
+		Errors: []*models.ErrorCount{
+			{Orchestrator: orchId, Region: aiTestFailingStats.Region, Pipeline: aiTestFailingStats.Pipeline, Model: aiTestFailingStats.Model, ErrorCode: "HTTP-STATUS-503", Count: 1},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		expectedStatus  int
+		expectedProfile *models.OrchestratorProfile
+	}{
+		{
+			name:            "Profile from the path",
+			path:            "/api/orchestrators/" + orchId,
+			expectedStatus:  http.StatusOK,
+			expectedProfile: expectedProfile,
+		},
+		{
+			name:            "Profile from a rewritten path",
+			path:            "/api/orchestrator?address=" + orchId,
+			expectedStatus:  http.StatusOK,
+			expectedProfile: expectedProfile,
+		},
+		{
+			name:           "Unknown orchestrator",
+			path:           "/api/orchestrators/0xunknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Missing address",
+			path:           "/api/orchestrators/",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+			for _, stats := range statsToInsert {
+				if err := db.Store.InsertStats(stats); err != nil {
+					t.Fatalf("Unexpected error when inserting stats: %v", err)
+				}
+			}
+
+			req, err := http.NewRequest("GET", tt.path, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(OrchestratorHandler)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var profile models.OrchestratorProfile
+			if err := json.Unmarshal(rr.Body.Bytes(), &profile); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			if profile.FirstSeen == 0 || profile.LastSeen < profile.FirstSeen {
+				t.Errorf("Unexpected first and last seen times: %v, %v", profile.FirstSeen, profile.LastSeen)
+			}
+			// the transcoding round trip score depends on the segment duration so only the AI scores are compared exactly
+			opts := []cmp.Option{
+				cmpopts.IgnoreFields(models.OrchestratorProfile{}, "FirstSeen", "LastSeen"),
+				cmpopts.IgnoreFields(models.OrchestratorScore{}, "RoundTripScore", "TotalScore"),
+			}
+			if diff := cmp.Diff(tt.expectedProfile, &profile, opts...); diff != "" {
+				t.Errorf("Handler returned unexpected profile (-want +got):\n%s", diff)
+			}
+			for _, s := range profile.Scores {
+				if s.JobType == "ai" && s.Region == aiTestBestStats.Region && (s.TotalScore < 0.92 || s.TotalScore > 0.94) {
+					t.Errorf("Unexpected AI score: %+v", s)
+				}
+			}
+		})
+	}
+}
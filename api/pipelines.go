@@ -7,6 +7,7 @@ import (
 	"github.com/livepeer/leaderboard-serverless/common"
 	"github.com/livepeer/leaderboard-serverless/db"
 	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
 	"github.com/livepeer/leaderboard-serverless/models"
 )
 
@@ -19,6 +20,11 @@ func PipelinesHandler(w http.ResponseWriter, r *http.Request) {
 
 	middleware.AddStandardHttpHeaders(w)
 
+	if err := validation.ValidateQuery(r, "/api/pipelines"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
 	query, err := common.ParseStatsQueryParams(r)
 	if err != nil {
 		common.HandleBadRequest(w, err)
@@ -1,19 +1,29 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"time"
 
 	"github.com/livepeer/leaderboard-serverless/common"
 	"github.com/livepeer/leaderboard-serverless/db"
 	"github.com/livepeer/leaderboard-serverless/middleware"
 	"github.com/livepeer/leaderboard-serverless/middleware/auth"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
 	"github.com/livepeer/leaderboard-serverless/models"
 )
 
+// maxBatchSize is the largest number of stats that can be submitted in a single request
+var maxBatchSize = common.EnvOrDefault("POST_STATS_MAX_BATCH_SIZE", 500).(int)
+
 // PostStatsHandler function Using AWS Lambda Proxy Request
+// The body is either a single JSON encoded Stats object or a batch of
+// Stats objects encoded as a JSON array or as newline delimited JSON.
 func PostStatsHandler(w http.ResponseWriter, r *http.Request) {
 	if err := db.CacheDB(); err != nil {
 		common.HandleInternalError(w, err)
@@ -29,6 +39,7 @@ func PostStatsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the signature covers the whole body, including every item of a batch
 	if ok := auth.IsAuthorized(
 		r.Header.Get("Authorization"),
 		body,
@@ -37,20 +48,30 @@ func PostStatsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var stats models.Stats
-	// Unmarshal the json, return 400 if error
-	if err := json.Unmarshal([]byte(body), &stats); err != nil {
+	records, isBatch, err := splitStatsBody(body, r.Header.Get("Content-Type"))
+	if err != nil {
 		common.HandleBadRequest(w, err)
 		return
 	}
 
-	if !isValidRegion(stats.Region) {
-		common.HandleBadRequest(w, errors.New("invalid region"))
+	if isBatch {
+		handleStatsBatch(w, records)
+		return
+	}
+
+	stats, err := parseStats(records[0])
+	if err != nil {
+		common.HandleValidationError(w, err)
 		return
 	}
 
-	if err := db.Store.InsertStats(&stats); err != nil {
+	if err := db.Store.InsertStats(stats); err != nil {
+		if errors.Is(err, models.ErrTimestampOutOfRange) {
+			common.HandleBadRequest(w, err)
+			return
+		}
 		common.HandleInternalError(w, err)
+		return
 	}
 
 	//Return inserts Object ID and  200 StatusCode response with AWS Lambda Proxy Response
@@ -58,16 +79,130 @@ func PostStatsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("ok"))
 }
 
-func isValidRegion(region string) bool {
+// handleStatsBatch validates every record of a batch and inserts the valid ones in a single transaction
+func handleStatsBatch(w http.ResponseWriter, records []json.RawMessage) {
+	if len(records) == 0 {
+		common.HandleBadRequest(w, errors.New("batch is empty"))
+		return
+	}
+	if len(records) > maxBatchSize {
+		common.HandleBadRequest(w, fmt.Errorf("batch exceeds the maximum size of %d", maxBatchSize))
+		return
+	}
+
+	result := models.BatchResult{Results: []*models.BatchItemResult{}}
+	acceptedStats := []*models.Stats{}
+	for i, record := range records {
+		itemResult := &models.BatchItemResult{Index: i, Status: models.BatchItemAccepted}
+		stats, err := parseStats(record)
+		if err != nil {
+			itemResult.Status = models.BatchItemRejected
+			itemResult.Error = err.Error()
+			result.Rejected++
+		} else {
+			acceptedStats = append(acceptedStats, stats)
+			result.Accepted++
+		}
+		result.Results = append(result.Results, itemResult)
+	}
+
+	if err := db.Store.InsertStatsBatch(acceptedStats); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	common.Logger.Debug("Batch processed with %d accepted and %d rejected stats", result.Accepted, result.Rejected)
+
+	resultsEncoded, err := json.Marshal(result)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Accepted == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.Write(resultsEncoded)
+}
+
+// splitStatsBody splits the body into individual JSON records and reports whether the body is a batch.
+// A body starting with '[' is a JSON array.  A body sent as NDJSON, or one with several lines that
+// is not a single JSON object, is split by line.  Anything else is a single Stats object.
+func splitStatsBody(body []byte, contentType string) ([]json.RawMessage, bool, error) {
+	trimmed := bytes.TrimSpace(body)
+
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var records []json.RawMessage
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, true, err
+		}
+		return records, true, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isNDJSON := mediaType == "application/x-ndjson" || mediaType == "application/ndjson"
+	if !isNDJSON && (!bytes.Contains(trimmed, []byte("\n")) || json.Valid(trimmed)) {
+		return []json.RawMessage{trimmed}, false, nil
+	}
+
+	records := []json.RawMessage{}
+	for _, line := range bytes.Split(trimmed, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		records = append(records, json.RawMessage(line))
+	}
+	return records, true, nil
+}
+
+// parseStats decodes a single JSON encoded Stats object and validates it against the Stats schema of the API specification
+func parseStats(record []byte) (*models.Stats, error) {
+	var stats models.Stats
+	if err := json.Unmarshal(record, &stats); err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(record, &decoded); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateSchema("Stats", decoded); err != nil {
+		return nil, err
+	}
+
+	if err := validateRegion(stats.Region, stats.JobType()); err != nil {
+		return nil, err
+	}
+
+	if _, _, err := common.ResolveEventTime(stats.Timestamp, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// validateRegion checks that the region is known for the job type of the stats.  Stats of a region that only
+// exists for the other job type would not be stored.
+func validateRegion(region, jobType string) error {
 	knownRegions, err := db.Store.Regions()
 	if err != nil {
-		common.Logger.Error(`Error getting regions while validating region: {region}`, err)
-		return false
+		common.Logger.Error("Error getting regions while validating region %v: %v", region, err)
+		return errors.New("invalid region")
 	}
+	known := false
 	for _, reg := range knownRegions {
-		if reg.Name == region {
-			return true
+		if reg.Name != region {
+			continue
+		}
+		if reg.Type == jobType {
+			return nil
 		}
+		known = true
+	}
+	if known {
+		return fmt.Errorf("invalid region for job type %v", jobType)
 	}
-	return false
+	return errors.New("invalid region")
 }
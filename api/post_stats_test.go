@@ -95,12 +95,12 @@ func runPostTests(t *testing.T, tests []struct {
 			common.Logger.Info("Validating that the request stats object was stored in the database. Expected: %v", statUnderTest)
 			//get the statsRetrievedFromDb object from the database
 			statsRetrievedFromDb, err := db.Store.RawStats(&models.StatsQuery{
-				Orchestrator: statUnderTest.Orchestrator,
-				Since:        testutils.GetUnixTimeMinusTenSec(),
-				Until:        testutils.GetUnixTimeInFiveSec(),
+				Orchestrators: []string{statUnderTest.Orchestrator},
+				Since:         testutils.GetUnixTimeMinusTenSec(),
+				Until:         testutils.GetUnixTimeInFiveSec(),
 
-				Pipeline: statUnderTest.Pipeline,
-				Model:    statUnderTest.Model,
+				Pipelines: testutils.Filter(statUnderTest.Pipeline),
+				Models:    testutils.Filter(statUnderTest.Model),
 			})
 			if err != nil {
 				t.Fatalf("Failed to get stats from database: %v", err)
@@ -114,3 +114,128 @@ func runPostTests(t *testing.T, tests []struct {
 		})
 	}
 }
+
+func TestPostStatsHandlerBatch(t *testing.T) {
+
+	testStats := testutils.GetTranscodingStats()
+	aiTestStats := testutils.GetAIStats()
+	invalidRegionStats := testutils.GetAIStats()
+	invalidRegionStats.Region = "NOWHERE"
+	// ATL is only a transcoding region
+	wrongJobTypeRegionStats := testutils.GetAIStats()
+	wrongJobTypeRegionStats.Region = "ATL"
+
+	marshal := func(stats models.Stats) string {
+		body, err := json.Marshal(stats)
+		if err != nil {
+			t.Fatalf("Failed to marshal request body: %v", err)
+		}
+		return string(body)
+	}
+
+	tests := []struct {
+		name             string
+		contentType      string
+		body             string
+		expectedStatus   int
+		expectedBody     string
+		expectedInserted int
+	}{
+		{
+			name:             "JSON array batch",
+			contentType:      "application/json",
+			body:             "[" + marshal(testStats) + "," + marshal(aiTestStats) + "]",
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `{"accepted":2,"rejected":0,"results":[{"index":0,"status":"accepted"},{"index":1,"status":"accepted"}]}`,
+			expectedInserted: 2,
+		},
+		{
+			name:             "NDJSON batch with rejected records",
+			contentType:      "application/x-ndjson",
+			body:             marshal(aiTestStats) + "\n" + marshal(invalidRegionStats) + "\n{not json}\n",
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `{"accepted":1,"rejected":2,"results":[{"index":0,"status":"accepted"},{"index":1,"status":"rejected","error":"invalid region"},{"index":2,"status":"rejected","error":"invalid character 'n' looking for beginning of object key string"}]}`,
+			expectedInserted: 1,
+		},
+		{
+			name:             "NDJSON batch without content type",
+			body:             marshal(aiTestStats) + "\n" + marshal(aiTestStats),
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `{"accepted":2,"rejected":0,"results":[{"index":0,"status":"accepted"},{"index":1,"status":"accepted"}]}`,
+			expectedInserted: 2,
+		},
+		{
+			name:             "Region of the other job type rejected",
+			contentType:      "application/json",
+			body:             "[" + marshal(testStats) + "," + marshal(wrongJobTypeRegionStats) + "]",
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `{"accepted":1,"rejected":1,"results":[{"index":0,"status":"accepted"},{"index":1,"status":"rejected","error":"invalid region for job type ai"}]}`,
+			expectedInserted: 1,
+		},
+		{
+			name:             "All records rejected",
+			contentType:      "application/json",
+			body:             "[" + marshal(invalidRegionStats) + "]",
+			expectedStatus:   http.StatusBadRequest,
+			expectedBody:     `{"accepted":0,"rejected":1,"results":[{"index":0,"status":"rejected","error":"invalid region"}]}`,
+			expectedInserted: 0,
+		},
+	}
+
+	os.Setenv("SECRET", "secret-key")
+	defer os.Unsetenv("SECRET")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+
+			body := []byte(tt.body)
+			authHeader := auth.EncryptHeader(body)
+
+			req, err := http.NewRequest("POST", "/post-stats", bytes.NewBuffer(body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req.Header.Set("Authorization", authHeader)
+
+			// Create a ResponseRecorder to record the response
+			rr := httptest.NewRecorder()
+
+			// Call the handler
+			handler := http.HandlerFunc(PostStatsHandler)
+			handler.ServeHTTP(rr, req)
+
+			// Check the status code
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+
+			// Compare the response body with the expected body
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Handler returned unexpected body: got %v want %v", rr.Body.String(), tt.expectedBody)
+			}
+
+			inserted := 0
+			for _, stats := range []models.Stats{testStats, aiTestStats} {
+				statsRetrievedFromDb, err := db.Store.RawStats(&models.StatsQuery{
+					Orchestrators: []string{stats.Orchestrator},
+					Since:         testutils.GetUnixTimeMinusTenSec(),
+					Until:         testutils.GetUnixTimeInFiveSec(),
+					Pipelines:     testutils.Filter(stats.Pipeline),
+					Models:        testutils.Filter(stats.Model),
+				})
+				if err != nil {
+					t.Fatalf("Failed to get stats from database: %v", err)
+				}
+				inserted += len(statsRetrievedFromDb)
+			}
+			if inserted != tt.expectedInserted {
+				t.Errorf("Unexpected number of stats stored in the database: got %v want %v", inserted, tt.expectedInserted)
+			}
+		})
+	}
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/livepeer/leaderboard-serverless/common"
 	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/export"
 	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
 	"github.com/livepeer/leaderboard-serverless/models"
 )
 
@@ -21,23 +23,107 @@ func RawStatsHandler(w http.ResponseWriter, r *http.Request) {
 
 	middleware.AddStandardHttpHeaders(w)
 
+	if err := validation.ValidateQuery(r, "/api/raw_stats"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
 	statsQuery, err := common.ParseStatsQueryParams(r)
 	if err != nil {
 		common.HandleBadRequest(w, err)
 		return
 	}
 
-	if statsQuery.Orchestrator == "" {
+	if len(statsQuery.Orchestrators) == 0 {
 		common.HandleBadRequest(w, errors.New("orchestrator is a required parameter"))
 		return
 	}
 
-	stats, err := db.Store.RawStats(statsQuery)
+	if err := common.ParseRawStatsPageParams(r, statsQuery); err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	format, err := common.ParseFormatParam(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	// CSV and NDJSON are streamed row by row as they are read from the database,
+	// so they are only limited when a limit is requested.  They are read for as
+	// long as the client is connected, the DB_TIMEOUT would cut off large exports.
+	if format != common.FormatJSON {
+		writer := export.NewWriter(w, format, "raw_stats", export.RawStatsHeader)
+		rows := 0
+		err := db.Store.EachRawStats(r.Context(), statsQuery, func(stat *models.Stats, cursor *models.RawStatsCursor) error {
+			rows++
+			return writer.Write(export.RawStatsRecord{Stats: stat})
+		})
+		if err != nil {
+			// once rows are written the status can no longer be changed
+			if rows == 0 {
+				common.HandleInternalError(w, err)
+			} else {
+				common.Logger.Error("Failed to export raw stats: %v", err)
+			}
+			return
+		}
+		if err := writer.Close(); err != nil {
+			common.Logger.Error("Failed to export raw stats: %v", err)
+		}
+		return
+	}
+
+	// without a limit or cursor all stats are returned by region, as they were before raw stats were paginated
+	queryParams := r.URL.Query()
+	if queryParams.Get("limit") == "" && queryParams.Get("cursor") == "" {
+		stats, err := db.Store.RawStats(statsQuery)
+		if err != nil {
+			common.HandleInternalError(w, err)
+			return
+		}
+		resultsEncoded, err := CreateRawStatsByRegion(stats)
+		if err != nil {
+			common.HandleInternalError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultsEncoded)
+		return
+	}
+
+	limit := statsQuery.Limit
+	if limit == 0 {
+		limit = common.DefaultPageLimit
+	}
+	// one more stat than fits on the page is read to know whether there is a next page
+	statsQuery.Limit = limit + 1
+	stats := []*models.Stats{}
+	var last *models.RawStatsCursor
+	hasNext := false
+	err = db.Store.EachRawStats(r.Context(), statsQuery, func(stat *models.Stats, cursor *models.RawStatsCursor) error {
+		if len(stats) == limit {
+			hasNext = true
+			return nil
+		}
+		stats = append(stats, stat)
+		last = cursor
+		return nil
+	})
 	if err != nil {
 		common.HandleInternalError(w, err)
 		return
 	}
-	resultsEncoded, err := CreateRawStats(stats)
+
+	next := ""
+	if hasNext {
+		if next, err = common.EncodeRawStatsCursor(last); err != nil {
+			common.HandleInternalError(w, err)
+			return
+		}
+	}
+	resultsEncoded, err := CreateRawStats(stats, limit, next)
 	if err != nil {
 		common.HandleInternalError(w, err)
 		return
@@ -47,8 +133,22 @@ func RawStatsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(resultsEncoded)
 }
 
-// CreateRawStats creates a map of raw stats by region
-func CreateRawStats(stats []*models.Stats) ([]byte, error) {
+// CreateRawStats creates a page of raw stats in the order the cursor walks them, each stat holds its region
+func CreateRawStats(stats []*models.Stats, limit int, next string) ([]byte, error) {
+	page := models.RawStatsPage{
+		Limit: limit,
+		Next:  next,
+		Stats: stats,
+	}
+	resultsEncoded, err := json.Marshal(page)
+	if err != nil {
+		return nil, err
+	}
+	return resultsEncoded, nil
+}
+
+// CreateRawStatsByRegion creates a map of raw stats by region, the stats of each region in the order they were sorted in
+func CreateRawStatsByRegion(stats []*models.Stats) ([]byte, error) {
 	results := make(map[string][]*models.Stats)
 	for _, stat := range stats {
 		results[stat.Region] = append(results[stat.Region], stat)
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"testing"
 
 	"github.com/livepeer/leaderboard-serverless/common"
@@ -40,13 +42,13 @@ func TestRawStatsHandler(t *testing.T) {
 
 	expectedRawStatsAITC := []*models.Stats{&aiTestFailingStats, &aiTestStatsPassingSlow, &aiTestBestStats}
 	//expected results are the expectedRawStatsTC1 expect the aiTestStatsPassingSlow, which has a different model and pipeline
-	expectedRawStatsResultsAITC, err := CreateRawStats([]*models.Stats{&aiTestFailingStats, &aiTestBestStats})
+	expectedRawStatsResultsAITC, err := CreateRawStatsByRegion([]*models.Stats{&aiTestFailingStats, &aiTestBestStats})
 	if err != nil {
 		t.Fatalf("Unexpected error when creating raw stats: %v", err)
 	}
 	expectedBodyStringAITC := string(expectedRawStatsResultsAITC)
 
-	expectedRawStatsResultsTransTC, err := CreateRawStats([]*models.Stats{&testStats})
+	expectedRawStatsResultsTransTC, err := CreateRawStatsByRegion([]*models.Stats{&testStats})
 	if err != nil {
 		t.Fatalf("Unexpected error when creating raw stats: %v", err)
 	}
@@ -79,13 +81,93 @@ func TestRawStatsHandler(t *testing.T) {
 			orchToTest:              &testStats,
 			statsToInsertBeforeTest: []*models.Stats{&aiTestFailingStats},
 			expectedStatus:          http.StatusOK,
-			expectedBody:            "{}",
+			expectedBody:            `{}`,
 		},
 	}
 
 	runRawStatsTests(t, tests)
 }
 
+func TestRawStatsHandlerPagination(t *testing.T) {
+	testutils.NewDB(t)
+
+	// three transcoding stats of the same orchestrator with increasing round trip times
+	for _, roundTripTime := range []float64{0.3, 0.1, 0.2} {
+		stats := testutils.GetTranscodingStats()
+		stats.RoundTripTime = roundTripTime
+		if err := db.Store.InsertStats(&stats); err != nil {
+			t.Fatalf("Unexpected error when inserting stats: %v", err)
+		}
+	}
+	testStats := testutils.GetTranscodingStats()
+
+	getPage := func(params string) *models.RawStatsPage {
+		t.Helper()
+		req, err := http.NewRequest("GET",
+			fmt.Sprintf("/raw-stats?orchestrator=%s&since=%s&until=%s&%s",
+				testStats.Orchestrator, testutils.GetUnixTimeMinusTenSecStr(), testutils.GetUnixTimeInFiveSecStr(), params),
+			nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(RawStatsHandler).ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Handler returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var page models.RawStatsPage
+		if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+		}
+		return &page
+	}
+	roundTripTimes := func(page *models.RawStatsPage) []float64 {
+		times := []float64{}
+		for _, stat := range page.Stats {
+			times = append(times, stat.RoundTripTime)
+		}
+		return times
+	}
+
+	first := getPage("sort=round_trip_time&order=asc&limit=2")
+	if got := roundTripTimes(first); !reflect.DeepEqual(got, []float64{0.1, 0.2}) || first.Limit != 2 || first.Next == "" {
+		t.Fatalf("Unexpected first page: %v, limit %v, next %q", got, first.Limit, first.Next)
+	}
+	second := getPage("sort=round_trip_time&order=asc&limit=2&cursor=" + first.Next)
+	if got := roundTripTimes(second); !reflect.DeepEqual(got, []float64{0.3}) || second.Next != "" {
+		t.Errorf("Unexpected last page: %v, next %q", got, second.Next)
+	}
+
+	// the most recent stats are returned first by default
+	if got := roundTripTimes(getPage("limit=3")); !reflect.DeepEqual(got, []float64{0.2, 0.1, 0.3}) {
+		t.Errorf("Unexpected default order: %v", got)
+	}
+
+	// without a limit or cursor every stat is returned by region
+	req, err := http.NewRequest("GET", fmt.Sprintf("/raw-stats?orchestrator=%s&since=%s&until=%s",
+		testStats.Orchestrator, testutils.GetUnixTimeMinusTenSecStr(), testutils.GetUnixTimeInFiveSecStr()), nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(RawStatsHandler).ServeHTTP(rr, req)
+	var byRegion map[string][]*models.Stats
+	if err := json.Unmarshal(rr.Body.Bytes(), &byRegion); err != nil || len(byRegion[testStats.Region]) != 3 {
+		t.Errorf("Expected the 3 stats by region without a limit, got %s", rr.Body.String())
+	}
+
+	// a cursor can not be used with a different sort
+	req, err = http.NewRequest("GET", fmt.Sprintf("/raw-stats?orchestrator=%s&cursor=%s", testStats.Orchestrator, first.Next), nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(RawStatsHandler).ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
 func runRawStatsTests(t *testing.T, tests []struct {
 	name                    string
 	orchToTest              *models.Stats
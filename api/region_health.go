@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// RegionHealthHandler handles a request for the health of the network as seen from each region.
+// Known regions without any tests are included so that a tester outage stands out.
+func RegionHealthHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/region_health"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	statsQuery, err := common.ParseStatsQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+	// the health of a region covers every orchestrator, pipeline and model
+	statsQuery.Orchestrators = nil
+	statsQuery.Pipelines = nil
+	statsQuery.Models = nil
+
+	health, err := db.Store.RegionHealth(statsQuery)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	knownRegions, err := db.Store.Regions()
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	tested := make(map[string]bool)
+	for _, regionHealth := range health {
+		tested[regionHealth.Region+"/"+regionHealth.JobType] = true
+	}
+	for _, region := range knownRegions {
+		if len(statsQuery.Regions) > 0 && !slices.Contains(statsQuery.Regions, region.Name) {
+			continue
+		}
+		if statsQuery.JobType != models.Unknown && region.Type != statsQuery.JobType.String() {
+			continue
+		}
+		if tested[region.Name+"/"+region.Type] {
+			continue
+		}
+		health = append(health, &models.RegionHealth{
+			Region:  region.Name,
+			JobType: region.Type,
+			Errors:  map[string]int{},
+		})
+	}
+	sort.SliceStable(health, func(i, j int) bool {
+		if health[i].Region != health[j].Region {
+			return health[i].Region < health[j].Region
+		}
+		return health[i].JobType < health[j].JobType
+	})
+
+	resultsEncoded, err := json.Marshal(map[string][]*models.RegionHealth{"regions": health})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
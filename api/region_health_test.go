@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestRegionHealthHandler(t *testing.T) {
+
+	// setup data
+	aiTestFailingStats := testutils.GetAIStats()
+	aiTestBestStats := testutils.GetBestAIStats()
+	aiTestBestStatsSecondOrch := testutils.GetBestAIStats()
+	aiTestBestStatsSecondOrch.Orchestrator = "orch2"
+	aiTestBestStatsSecondOrch.RoundTripTime = 0.3
+	transcodingStats := testutils.GetTranscodingStats()
+
+	statsToInsert := []*models.Stats{&aiTestFailingStats, &aiTestBestStats, &aiTestBestStatsSecondOrch, &transcodingStats}
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedHealth []*models.RegionHealth
+	}{
+		{
+			name:           "Health of a tested region",
+			queryParams:    "job_type=ai&region=" + aiTestBestStats.Region,
+			expectedStatus: http.StatusOK,
+			expectedHealth: []*models.RegionHealth{
+				{Region: aiTestBestStats.Region, JobType: "ai", Orchestrators: 2, Tests: 2, SuccessRate: 1, MedianRTT: 0.2, P90RTT: 0.28, Errors: map[string]int{}},
+			},
+		},
+		{
+			name:           "Errors of a failing region",
+			queryParams:    "job_type=ai&region=" + aiTestFailingStats.Region,
+			expectedStatus: http.StatusOK,
+			expectedHealth: []*models.RegionHealth{
+				{Region: aiTestFailingStats.Region, JobType: "ai", Orchestrators: 1, Tests: 1, Errors: map[string]int{"HTTP-STATUS-503": 1}},
+			},
+		},
+		{
+			name:           "Every job type of a region",
+			queryParams:    "region=" + transcodingStats.Region,
+			expectedStatus: http.StatusOK,
+			expectedHealth: []*models.RegionHealth{
+				{Region: aiTestFailingStats.Region, JobType: "ai", Orchestrators: 1, Tests: 1, Errors: map[string]int{"HTTP-STATUS-503": 1}},
+				{Region: transcodingStats.Region, JobType: "transcoding", Orchestrators: 1, Tests: 1, SuccessRate: 1, MedianRTT: transcodingStats.RoundTripTime, P90RTT: transcodingStats.RoundTripTime, Errors: map[string]int{}},
+			},
+		},
+		{
+			name:           "Known region without tests",
+			queryParams:    "job_type=ai&region=FRA",
+			expectedStatus: http.StatusOK,
+			expectedHealth: []*models.RegionHealth{
+				{Region: "FRA", JobType: "ai", Errors: map[string]int{}},
+			},
+		},
+		{
+			name:           "Invalid job type",
+			queryParams:    "job_type=video",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+			for _, stats := range statsToInsert {
+				if err := db.Store.InsertStats(stats); err != nil {
+					t.Fatalf("Unexpected error when inserting stats: %v", err)
+				}
+			}
+
+			req, err := http.NewRequest("GET", "/api/region_health?"+tt.queryParams, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(RegionHealthHandler)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var responseBody map[string][]*models.RegionHealth
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseBody); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			health := responseBody["regions"]
+			for _, regionHealth := range health {
+				if regionHealth.Tests > 0 && regionHealth.LastReceived == 0 {
+					t.Errorf("Expected the time of the last received test for region %s", regionHealth.Region)
+				}
+			}
+			// the time of the last received test depends on when the test runs
+			opts := []cmp.Option{cmpopts.IgnoreFields(models.RegionHealth{}, "LastReceived"), cmpopts.EquateApprox(0, 1e-9)}
+			if diff := cmp.Diff(tt.expectedHealth, health, opts...); diff != "" {
+				t.Errorf("Handler returned unexpected region health (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
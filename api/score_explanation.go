@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+)
+
+// ScoreExplanationHandler handles a request for the inputs and intermediate values of the score of an orchestrator
+// in a region.  The orchestrator parameter is required, the region explained is the explain_region parameter, which
+// must be one of the regions filtered on, or, without it, the single region parameter.
+func ScoreExplanationHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/score_explanation"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	statsQuery, err := common.ParseStatsQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	if len(statsQuery.Orchestrators) != 1 {
+		common.HandleBadRequest(w, errors.New("a single orchestrator is a required parameter"))
+		return
+	}
+	region := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("explain_region")))
+	if region == "" {
+		if len(statsQuery.Regions) != 1 {
+			common.HandleBadRequest(w, errors.New("a single region or an explain_region is a required parameter"))
+			return
+		}
+		region = statsQuery.Regions[0]
+	} else if len(statsQuery.Regions) > 0 && !slices.Contains(statsQuery.Regions, region) {
+		common.HandleBadRequest(w, errors.New("the explain_region must be one of the regions filtered on"))
+		return
+	}
+	if err := statsQuery.CanScore(); err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	// the score is explained as it is calculated for the aggregated stats of all orchestrators in the regions
+	// filtered on, since the median RTT is taken across all of them
+	orchestrator := statsQuery.Orchestrators[0]
+	statsQuery.Orchestrators = nil
+
+	aggrStatResult, err := db.Store.AggregatedStats(statsQuery)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	explanation := score.ExplainScore(aggrStatResult, orchestrator, region)
+	if explanation == nil {
+		common.RespondWithError(w, models.ErrScoreNotFound, http.StatusNotFound)
+		return
+	}
+
+	// the stats were aggregated with the half-life of the query when it was given
+	if statsQuery.HalfLifeMinutes != nil {
+		for _, stateExplanation := range []*models.ScoreExplanation{explanation, explanation.Warm, explanation.Cold} {
+			if stateExplanation != nil {
+				config := *stateExplanation.Config
+				config.HalfLifeMinutes = *statsQuery.HalfLifeMinutes
+				stateExplanation.Config = &config
+			}
+		}
+	}
+
+	resultsEncoded, err := json.Marshal(explanation)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
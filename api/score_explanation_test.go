@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestScoreExplanationHandler(t *testing.T) {
+
+	// setup data
+	aiTestBestStats := testutils.GetBestAIStats()
+
+	aiTestSecondOrchStats := testutils.GetBestAIStats()
+	aiTestSecondOrchStats.Orchestrator = "orch2"
+	aiTestSecondOrchStats.RoundTripTime = 4.2
+
+	allStats := []*models.Stats{&aiTestBestStats, &aiTestSecondOrchStats}
+	aiParams := "pipeline=" + url.QueryEscape(aiTestBestStats.Pipeline) + "&model=" + url.QueryEscape(aiTestBestStats.Model) +
+		"&since=" + testutils.GetUnixTimeMinus24HrStr() + "&until=" + testutils.GetUnixTimeInFiveSecStr()
+
+	// test cases
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+	}{
+		{
+			name:           "Explanation for AI orchestrator",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&region=" + aiTestBestStats.Region,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Explanation for a region the orchestrator was not tested from",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&region=SIN",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Explanation for a region among the regions filtered on",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&region=" + aiTestBestStats.Region + ",LAX&explain_region=" + aiTestBestStats.Region,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Explanation for a lower case region",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&region=" + aiTestBestStats.Region + ",LAX&explain_region=" + strings.ToLower(aiTestBestStats.Region),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Explanation for a region not filtered on",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&region=" + aiTestBestStats.Region + ",LAX&explain_region=SIN",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Explanation across all regions",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&explain_region=" + aiTestBestStats.Region,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing region",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Several regions without the region to explain",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&region=" + aiTestBestStats.Region + ",LAX",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing orchestrator",
+			queryParams:    aiParams + "&region=" + aiTestBestStats.Region,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+
+			// insert the stats before the test
+			for _, stats := range allStats {
+				if err := db.Store.InsertStats(stats); err != nil {
+					t.Fatalf("Unexpected error when inserting stats: %v", err)
+				}
+			}
+
+			// Create a new HTTP request with query parameters
+			req, err := http.NewRequest("GET", "/score_explanation?"+tt.queryParams, bytes.NewBuffer([]byte{}))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			// Create a ResponseRecorder to record the response
+			rr := httptest.NewRecorder()
+
+			// Call the handler
+			handler := http.HandlerFunc(ScoreExplanationHandler)
+			handler.ServeHTTP(rr, req)
+
+			// Check the status code
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var explanation models.ScoreExplanation
+			if err := json.Unmarshal(rr.Body.Bytes(), &explanation); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			if explanation.Scorer != score.ScorerAIExpDecay || explanation.Config.Version != 1 {
+				t.Errorf("Handler returned unexpected scorer %v and configuration %+v", explanation.Scorer, explanation.Config)
+			}
+			// the median RTT is taken across both orchestrators
+			if explanation.Inputs.Tests != 1 || explanation.Inputs.MedianRTT <= aiTestBestStats.RoundTripTime || explanation.Inputs.MedianRTT >= aiTestSecondOrchStats.RoundTripTime {
+				t.Errorf("Handler returned unexpected inputs: %+v", explanation.Inputs)
+			}
+			if len(explanation.Steps) == 0 || explanation.Steps[len(explanation.Steps)-1].Value != explanation.TotalScore {
+				t.Errorf("Handler returned steps that do not end in the score %v: %+v", explanation.TotalScore, explanation.Steps)
+			}
+			if explanation.Cold == nil || explanation.Cold.Inputs.Tests != 1 {
+				t.Errorf("Handler returned no explanation of the cold model state: %+v", explanation.Cold)
+			}
+		})
+	}
+}
+
+func TestScoreExplanationMatchesAggregatedStats(t *testing.T) {
+	testutils.NewDB(t)
+
+	best := testutils.GetBestAIStats()
+	slow := testutils.GetBestAIStats()
+	slow.Orchestrator = "orch2"
+	slow.RoundTripTime = 4.2
+	// a fast orchestrator in another region lowers the median RTT across all regions
+	fast := testutils.GetBestAIStats()
+	fast.Orchestrator = "orch3"
+	fast.Region = "LAX"
+	fast.RoundTripTime = 0.1
+	for _, stats := range []*models.Stats{&best, &slow, &fast} {
+		if err := db.Store.InsertStats(stats); err != nil {
+			t.Fatalf("Unexpected error when inserting stats: %v", err)
+		}
+	}
+	aiParams := "pipeline=" + url.QueryEscape(best.Pipeline) + "&model=" + url.QueryEscape(best.Model) +
+		"&since=" + testutils.GetUnixTimeMinus24HrStr() + "&until=" + testutils.GetUnixTimeInFiveSecStr()
+
+	get := func(handler http.HandlerFunc, path, queryParams string, v interface{}) {
+		t.Helper()
+		req, err := http.NewRequest("GET", path+"?"+queryParams, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Handler returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), v); err != nil {
+			t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+		}
+	}
+
+	scores := make(map[string]float64)
+	for name, regionParams := range map[string]string{
+		"region":      "&region=" + best.Region,
+		"all regions": "",
+	} {
+		var aggrStats map[string]map[string]*models.AggregatedStats
+		get(AggregatedStatsHandler, "/aggregated_stats", aiParams+regionParams, &aggrStats)
+		var explanation models.ScoreExplanation
+		get(ScoreExplanationHandler, "/score_explanation", aiParams+regionParams+"&orchestrator="+best.Orchestrator+"&explain_region="+best.Region, &explanation)
+
+		stats := aggrStats[best.Orchestrator][best.Region]
+		if stats == nil || stats.TotalScore != explanation.TotalScore {
+			t.Errorf("Expected the explained score to equal the aggregated stats in %v, got %v and %+v", name, explanation.TotalScore, stats)
+		}
+		scores[name] = explanation.TotalScore
+	}
+	if scores["region"] == scores["all regions"] {
+		t.Errorf("Expected the score in the region to differ from the score across all regions, got %v", scores)
+	}
+}
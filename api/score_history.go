@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+)
+
+// ScoreHistoryHandler handles a request for the time-bucketed score history of an orchestrator
+// orchestrator parameter is required
+func ScoreHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/score_history"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	statsQuery, err := common.ParseStatsQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	if len(statsQuery.Orchestrators) != 1 {
+		common.HandleBadRequest(w, errors.New("a single orchestrator is a required parameter"))
+		return
+	}
+	if err := statsQuery.CanScore(); err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+	orchestrator := statsQuery.Orchestrators[0]
+
+	bucket, bucketSize, err := common.ParseBucketParam(r, statsQuery)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	buckets, err := db.Store.StatsHistory(statsQuery, bucketSize)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	series := score.CreateScoreHistory(buckets)[orchestrator]
+	if series == nil {
+		series = []*models.ScoreSeries{}
+	}
+
+	resultsEncoded, err := json.Marshal(models.ScoreHistory{
+		Orchestrator: orchestrator,
+		Bucket:       bucket,
+		Series:       series,
+	})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
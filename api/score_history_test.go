@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestScoreHistoryHandler(t *testing.T) {
+
+	// setup data
+	aiTestBestStats := testutils.GetBestAIStats()
+
+	aiTestSecondOrchStats := testutils.GetBestAIStats()
+	aiTestSecondOrchStats.Orchestrator = "orch2"
+	aiTestSecondOrchStats.RoundTripTime = 4.2
+
+	allStats := []*models.Stats{&aiTestBestStats, &aiTestSecondOrchStats}
+	aiParams := "pipeline=" + url.QueryEscape(aiTestBestStats.Pipeline) + "&model=" + url.QueryEscape(aiTestBestStats.Model) +
+		"&since=" + testutils.GetUnixTimeMinus24HrStr() + "&until=" + testutils.GetUnixTimeInFiveSecStr()
+
+	// test cases
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedSeries int
+	}{
+		{
+			name:           "History for AI orchestrator",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&bucket=5m",
+			expectedStatus: http.StatusOK,
+			expectedSeries: 1,
+		},
+		{
+			name:           "History for unknown orchestrator",
+			queryParams:    aiParams + "&orchestrator=unknown",
+			expectedStatus: http.StatusOK,
+			expectedSeries: 0,
+		},
+		{
+			name:           "Missing orchestrator",
+			queryParams:    aiParams,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid bucket",
+			queryParams:    aiParams + "&orchestrator=" + aiTestBestStats.Orchestrator + "&bucket=2w",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+
+			// insert the stats before the test
+			for _, stats := range allStats {
+				if err := db.Store.InsertStats(stats); err != nil {
+					t.Fatalf("Unexpected error when inserting stats: %v", err)
+				}
+			}
+
+			// Create a new HTTP request with query parameters
+			req, err := http.NewRequest("GET", "/score-history?"+tt.queryParams, bytes.NewBuffer([]byte{}))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			// Create a ResponseRecorder to record the response
+			rr := httptest.NewRecorder()
+
+			// Call the handler
+			handler := http.HandlerFunc(ScoreHistoryHandler)
+			handler.ServeHTTP(rr, req)
+
+			// Check the status code
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var history models.ScoreHistory
+			if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			if len(history.Series) != tt.expectedSeries {
+				t.Fatalf("Handler returned unexpected number of series: got %v want %v", len(history.Series), tt.expectedSeries)
+			}
+			for _, series := range history.Series {
+				if series.Region != aiTestBestStats.Region {
+					t.Errorf("Handler returned unexpected region: got %v want %v", series.Region, aiTestBestStats.Region)
+				}
+				if len(series.Points) == 0 {
+					t.Fatalf("Handler returned a series without points")
+				}
+				// the orchestrator is the only one with a successful test at the median RTT
+				lastPoint := series.Points[len(series.Points)-1]
+				if lastPoint.SuccessRate != 1 || lastPoint.TotalScore <= 0 {
+					t.Errorf("Handler returned unexpected point: %+v", lastPoint.AggregatedStats)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/stream"
+)
+
+// streamHeartbeatInterval is how often a comment is sent to keep idle streams open
+const streamHeartbeatInterval = 15 * time.Second
+
+// StreamStatsHandler streams newly ingested stats as server-sent events
+func StreamStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		common.HandleInternalError(w, errors.New("streaming is not supported"))
+		return
+	}
+
+	if err := validation.ValidateQuery(r, "/api/stream_stats"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	statsQuery, err := common.ParseStatsQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	middleware.AddStreamHttpHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	subscriber := stream.DefaultHub.Subscribe(statsQuery)
+	defer stream.DefaultHub.Unsubscribe(subscriber)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			common.Logger.Debug("Stats stream closed by the client")
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case event := <-subscriber.Events:
+			if err := writeStatsEvent(w, event); err != nil {
+				common.Logger.Error("Failed to write stats event: %v", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeStatsEvent writes a single server-sent event for the stats
+func writeStatsEvent(w http.ResponseWriter, event *stream.Event) error {
+	data, err := json.Marshal(event.Stats)
+	if err != nil {
+		return err
+	}
+	// the event name is the job type so clients can listen for a single job type
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Stats.JobType(), data)
+	return err
+}
@@ -3,11 +3,13 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/livepeer/leaderboard-serverless/common"
 	"github.com/livepeer/leaderboard-serverless/db"
 	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
 	"github.com/livepeer/leaderboard-serverless/models"
 	"github.com/livepeer/leaderboard-serverless/score"
 )
@@ -24,8 +26,13 @@ func TopAiScoreHandler(w http.ResponseWriter, r *http.Request) {
 
 	middleware.AddStandardHttpHeaders(w)
 
+	if err := validation.ValidateQuery(r, "/api/top_ai_score"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
 	//get orchestratorId from query and build the query
-	orchestratorId := r.URL.Query().Get("orchestrator")
+	orchestratorId := strings.ToLower(r.URL.Query().Get("orchestrator"))
 
 	topStatsForOrch, err := db.Store.BestAIRegion(orchestratorId)
 	if err != nil {
@@ -44,11 +51,11 @@ func TopAiScoreHandler(w http.ResponseWriter, r *http.Request) {
 	// so we can calculate the top scores using the median RTT
 
 	query := &models.StatsQuery{
-		Since:    common.GetDefaultSince(),
-		Until:    time.Now().UTC(),
-		JobType:  models.AI,
-		Model:    topStatsForOrch.Model,
-		Pipeline: topStatsForOrch.Pipeline,
+		Since:     common.GetDefaultSince(),
+		Until:     time.Now().UTC(),
+		JobType:   models.AI,
+		Models:    []string{topStatsForOrch.Model},
+		Pipelines: []string{topStatsForOrch.Pipeline},
 	}
 	aggrStatResult, err := db.Store.AggregatedStats(query)
 	if err != nil {
@@ -61,13 +68,21 @@ func TopAiScoreHandler(w http.ResponseWriter, r *http.Request) {
 
 	// now that we have the aggregated stats for this model and pipeline
 	// let's find the record for this orchestrator and region
-	// so that we can return the top scores
+	// so that we can return the top scores.  It is missing when the orchestrator
+	// is not scored in the model state of the scoring configuration.
+	topStats, ok := aggregatedStats[orchestratorId][topStatsForOrch.Region]
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+		return
+	}
 	topScore := models.Score{
 		Orchestrator: topStatsForOrch.Orchestrator,
 		Region:       topStatsForOrch.Region,
-		Value:        aggregatedStats[orchestratorId][topStatsForOrch.Region].TotalScore,
+		Value:        topStats.TotalScore,
 		Model:        topStatsForOrch.Model,
 		Pipeline:     topStatsForOrch.Pipeline,
+		ScoreVersion: topStats.ScoreVersion,
 	}
 
 	resultsEncoded, err := json.Marshal(topScore)
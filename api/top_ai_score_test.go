@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/livepeer/leaderboard-serverless/common"
@@ -47,21 +48,28 @@ func TestTopAIScoreHandler(t *testing.T) {
 			orchToTest:              aiTestBestStats.Orchestrator,
 			statsToInsertBeforeTest: []*models.Stats{&aiTestFailingStats, &aiTestStatsPassingSlow, &aiTestBestStats},
 			expectedStatus:          http.StatusOK,
-			expectedBody:            fmt.Sprintf(`{"region":"%s","orchestrator":"%s","value":0.9299999999999999,"model":"%s","pipeline":"%s"}`, aiTestBestStats.Region, aiTestBestStats.Orchestrator, aiTestBestStats.Model, aiTestBestStats.Pipeline),
+			expectedBody:            fmt.Sprintf(`{"region":"%s","orchestrator":"%s","value":0.9299999999999999,"model":"%s","pipeline":"%s","score_version":1}`, aiTestBestStats.Region, aiTestBestStats.Orchestrator, aiTestBestStats.Model, aiTestBestStats.Pipeline),
+		},
+		{
+			name:                    "Top score for AI - mixed case address",
+			orchToTest:              "0x" + strings.ToUpper(aiTestBestStats.Orchestrator[2:]),
+			statsToInsertBeforeTest: []*models.Stats{&aiTestFailingStats, &aiTestStatsPassingSlow, &aiTestBestStats},
+			expectedStatus:          http.StatusOK,
+			expectedBody:            fmt.Sprintf(`{"region":"%s","orchestrator":"%s","value":0.9299999999999999,"model":"%s","pipeline":"%s","score_version":1}`, aiTestBestStats.Region, aiTestBestStats.Orchestrator, aiTestBestStats.Model, aiTestBestStats.Pipeline),
 		},
 		{
 			name:                    "Top score for AI - different region",
 			orchToTest:              aiTestStatsPassingSlow.Orchestrator,
 			statsToInsertBeforeTest: []*models.Stats{&aiTestFailingStats, &aiTestStatsPassingSlow},
 			expectedStatus:          http.StatusOK,
-			expectedBody:            fmt.Sprintf(`{"region":"%s","orchestrator":"%s","value":0.9299999999999999,"model":"%s","pipeline":"%s"}`, aiTestStatsPassingSlow.Region, aiTestStatsPassingSlow.Orchestrator, aiTestStatsPassingSlow.Model, aiTestStatsPassingSlow.Pipeline),
+			expectedBody:            fmt.Sprintf(`{"region":"%s","orchestrator":"%s","value":0.9299999999999999,"model":"%s","pipeline":"%s","score_version":1}`, aiTestStatsPassingSlow.Region, aiTestStatsPassingSlow.Orchestrator, aiTestStatsPassingSlow.Model, aiTestStatsPassingSlow.Pipeline),
 		},
 		{
 			name:                    "Top score for AI - different orchs",
 			orchToTest:              aiTestBestStatsSecondOrch.Orchestrator,
 			statsToInsertBeforeTest: []*models.Stats{&aiTestBestStatsSecondOrch, &aiTestStatsPassingSlow},
 			expectedStatus:          http.StatusOK,
-			expectedBody:            fmt.Sprintf(`{"region":"%s","orchestrator":"%s","value":0.9299999999999999,"model":"%s","pipeline":"%s"}`, aiTestBestStatsSecondOrch.Region, aiTestBestStatsSecondOrch.Orchestrator, aiTestBestStatsSecondOrch.Model, aiTestBestStatsSecondOrch.Pipeline),
+			expectedBody:            fmt.Sprintf(`{"region":"%s","orchestrator":"%s","value":0.9299999999999999,"model":"%s","pipeline":"%s","score_version":1}`, aiTestBestStatsSecondOrch.Region, aiTestBestStatsSecondOrch.Orchestrator, aiTestBestStatsSecondOrch.Model, aiTestBestStatsSecondOrch.Pipeline),
 		},
 		{
 			name:                    "No top score for AI",
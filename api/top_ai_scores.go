@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+)
+
+// TopAiScoresHandler handles a request for the best region and score of an orchestrator
+// for every AI pipeline and model it was tested on.  Scores are ordered from best to worst.
+func TopAiScoresHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.AddStandardHttpHeaders(w)
+
+	if err := validation.ValidateQuery(r, "/api/top_ai_scores"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	orchestratorId := strings.ToLower(r.URL.Query().Get("orchestrator"))
+
+	bestStatsForOrch, err := db.Store.BestAIRegions(orchestratorId)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	scores := []*models.Score{}
+	for _, best := range bestStatsForOrch {
+		// every pipeline and model is scored against the median RTT
+		// of all orchestrators tested on that pipeline and model
+		query := &models.StatsQuery{
+			Since:     common.GetDefaultSince(),
+			Until:     time.Now().UTC(),
+			JobType:   models.AI,
+			Models:    []string{best.Model},
+			Pipelines: []string{best.Pipeline},
+		}
+		aggrStatResult, err := db.Store.AggregatedStats(query)
+		if err != nil {
+			common.HandleInternalError(w, err)
+			return
+		}
+		aggregatedStats := score.CreateAggregatedStats(aggrStatResult)
+
+		topScore := &models.Score{
+			Orchestrator: best.Orchestrator,
+			Region:       best.Region,
+			Model:        best.Model,
+			Pipeline:     best.Pipeline,
+		}
+		if stats, ok := aggregatedStats[best.Orchestrator][best.Region]; ok {
+			topScore.Value = stats.TotalScore
+			topScore.ScoreVersion = stats.ScoreVersion
+		}
+		scores = append(scores, topScore)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Value > scores[j].Value
+	})
+
+	resultsEncoded, err := json.Marshal(map[string][]*models.Score{"scores": scores})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestTopAIScoresHandler(t *testing.T) {
+
+	// setup data
+	testStats := testutils.GetTranscodingStats()
+
+	aiTestFailingStats := testutils.GetAIStats()
+
+	aiTestBestStats := testutils.GetBestAIStats()
+
+	aiTestStatsPassingSlow := testutils.GetAIStats()
+	aiTestStatsPassingSlow.SuccessRate = 1
+	aiTestStatsPassingSlow.RoundTripTime = 24.1
+	aiTestStatsPassingSlow.Region = "FRA"
+	aiTestStatsPassingSlow.Model = "model1"
+	aiTestStatsPassingSlow.Pipeline = "pipeline1"
+
+	// a faster orchestrator lowers the median RTT of model1 so the slow stats score worse
+	aiTestStatsFastSecondOrch := aiTestStatsPassingSlow
+	aiTestStatsFastSecondOrch.Orchestrator = "orch2"
+	aiTestStatsFastSecondOrch.RoundTripTime = 0.1
+
+	// test cases
+	tests := []struct {
+		name                    string
+		orchToTest              string
+		statsToInsertBeforeTest []*models.Stats
+		expectedStatus          int
+		expectedScores          []*models.Score
+	}{
+		{
+			name:                    "Best region of every pipeline and model",
+			orchToTest:              aiTestBestStats.Orchestrator,
+			statsToInsertBeforeTest: []*models.Stats{&aiTestFailingStats, &aiTestBestStats, &aiTestStatsPassingSlow, &aiTestStatsFastSecondOrch},
+			expectedStatus:          http.StatusOK,
+			expectedScores: []*models.Score{
+				{Orchestrator: aiTestBestStats.Orchestrator, Region: aiTestBestStats.Region, Model: aiTestBestStats.Model, Pipeline: aiTestBestStats.Pipeline},
+				{Orchestrator: aiTestStatsPassingSlow.Orchestrator, Region: aiTestStatsPassingSlow.Region, Model: aiTestStatsPassingSlow.Model, Pipeline: aiTestStatsPassingSlow.Pipeline},
+			},
+		},
+		{
+			name:                    "No AI scores",
+			orchToTest:              testStats.Orchestrator,
+			statsToInsertBeforeTest: []*models.Stats{&testStats},
+			expectedStatus:          http.StatusOK,
+			expectedScores:          []*models.Score{},
+		},
+		{
+			name:           "Missing orchestrator",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+
+			for _, stats := range tt.statsToInsertBeforeTest {
+				if err := db.Store.InsertStats(stats); err != nil {
+					t.Fatalf("Unexpected error when inserting stats: %v", err)
+				}
+			}
+
+			req, err := http.NewRequest("GET", "/api/top_ai_scores?orchestrator="+tt.orchToTest, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(TopAiScoresHandler)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var responseBody map[string][]*models.Score
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseBody); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			scores := responseBody["scores"]
+			if len(scores) != len(tt.expectedScores) {
+				t.Fatalf("Handler returned unexpected scores: got %v want %v", rr.Body.String(), tt.expectedScores)
+			}
+			for i, expected := range tt.expectedScores {
+				got := scores[i]
+				if got.Orchestrator != expected.Orchestrator || got.Region != expected.Region || got.Model != expected.Model || got.Pipeline != expected.Pipeline {
+					t.Errorf("Unexpected score at position %d: got %+v want %+v", i, got, expected)
+				}
+				if got.Value <= 0 {
+					t.Errorf("Expected a positive score at position %d, got %v", i, got.Value)
+				}
+				if i > 0 && got.Value > scores[i-1].Value {
+					t.Errorf("Scores are not ordered from best to worst: %v", rr.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/auth"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// WebhookDeliveriesHandler handles a request for the delivery history of a webhook, most recent first.
+// It is authenticated the same way as the webhooks, with the HMAC of the request and its empty body.
+func WebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.HandlePreflightRequest(w, r)
+
+	if ok := auth.IsRequestAuthorized(r, nil, time.Now()); !ok {
+		common.RespondWithError(w, errors.New("request can not be authenticated"), http.StatusForbidden)
+		return
+	}
+
+	if err := validation.ValidateQuery(r, "/api/webhook_deliveries"); err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	webhookID, err := strconv.ParseInt(r.URL.Query().Get("webhook_id"), 10, 64)
+	if err != nil {
+		common.HandleBadRequest(w, errors.New("webhook_id must be the integer id of a webhook"))
+		return
+	}
+	page, err := common.ParsePageQueryParams(r)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	deliveries, err := db.Store.WebhookDeliveries(webhookID, page.Limit)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	resultsEncoded, err := json.Marshal(map[string][]*models.WebhookDelivery{"deliveries": deliveries})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware"
+	"github.com/livepeer/leaderboard-serverless/middleware/auth"
+	"github.com/livepeer/leaderboard-serverless/middleware/validation"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// defaultWebhookDurationMinutes is the window a webhook rule is evaluated over when no duration is given
+const defaultWebhookDurationMinutes = 60
+
+// WebhooksHandler lists, registers and removes webhooks.  Every request is authenticated with the HMAC of its
+// method, path, query, timestamp and body, which is empty when listing or removing webhooks.
+func WebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db.CacheDB(); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	middleware.HandlePreflightRequest(w, r)
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		common.HandleBadRequest(w, err)
+		return
+	}
+
+	if ok := auth.IsRequestAuthorized(r, body, time.Now()); !ok {
+		common.RespondWithError(w, errors.New("request can not be authenticated"), http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		listWebhooks(w)
+	case http.MethodPost:
+		registerWebhook(w, body)
+	case http.MethodDelete:
+		deleteWebhook(w, r)
+	default:
+		common.RespondWithError(w, errors.New("method not allowed"), http.StatusMethodNotAllowed)
+	}
+}
+
+// listWebhooks responds with every registered webhook without their secrets
+func listWebhooks(w http.ResponseWriter) {
+	webhooks, err := db.Store.Webhooks()
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	resultsEncoded, err := json.Marshal(map[string][]*models.Webhook{"webhooks": webhooks})
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultsEncoded)
+}
+
+// registerWebhook stores the webhook and responds with it, including the secret notifications are signed with
+func registerWebhook(w http.ResponseWriter, body []byte) {
+	webhook, err := parseWebhook(body)
+	if err != nil {
+		common.HandleValidationError(w, err)
+		return
+	}
+
+	if err := db.Store.InsertWebhook(webhook); err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	resultsEncoded, err := json.Marshal(webhook)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resultsEncoded)
+}
+
+// deleteWebhook removes the webhook given by the 'id' parameter
+func deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		common.HandleBadRequest(w, errors.New("id must be the integer id of a webhook"))
+		return
+	}
+
+	deleted, err := db.Store.DeleteWebhook(id)
+	if err != nil {
+		common.HandleInternalError(w, err)
+		return
+	}
+	if !deleted {
+		common.RespondWithError(w, models.ErrWebhookNotFound, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseWebhook decodes a webhook registration, validates it against the WebhookRequest schema of the
+// API specification and fills in the defaults
+func parseWebhook(body []byte) (*models.Webhook, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateSchema("WebhookRequest", decoded); err != nil {
+		return nil, err
+	}
+	var webhook models.Webhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, err
+	}
+
+	if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+	webhook.Orchestrator = strings.ToLower(webhook.Orchestrator)
+	webhook.Region = strings.ToUpper(webhook.Region)
+	webhook.Metric = strings.ToLower(webhook.Metric)
+	webhook.Condition = strings.ToLower(webhook.Condition)
+
+	// AI scores are only comparable within a pipeline and model
+	webhook.JobType = strings.ToLower(webhook.JobType)
+	if webhook.JobType == "" {
+		webhook.JobType = models.Transcoding.String()
+		if webhook.Pipeline != "" {
+			webhook.JobType = models.AI.String()
+		}
+	}
+	if webhook.JobType == models.AI.String() {
+		if webhook.Pipeline == "" {
+			return nil, models.ErrMissingPipeline
+		}
+		if webhook.Model == "" {
+			return nil, models.ErrMissingModel
+		}
+	} else if webhook.Pipeline != "" || webhook.Model != "" {
+		return nil, errors.New("pipeline and model are only supported for the 'ai' job_type")
+	}
+
+	if webhook.DurationMinutes == 0 {
+		webhook.DurationMinutes = defaultWebhookDurationMinutes
+	}
+	webhook.ID = 0
+	webhook.CreatedAt = 0
+	return &webhook, nil
+}
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/middleware/auth"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+	"github.com/livepeer/leaderboard-serverless/webhook"
+)
+
+func TestWebhooksHandler(t *testing.T) {
+	os.Setenv("SECRET", "secret-key")
+	defer os.Unsetenv("SECRET")
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedHook   *models.Webhook
+	}{
+		{
+			name:           "Transcoding score webhook with defaults",
+			body:           `{"url":"https://example.com/hook","secret":"0123456789abcdef","orchestrator":"0xABC","metric":"score","condition":"below","threshold":0.5}`,
+			expectedStatus: http.StatusCreated,
+			expectedHook:   &models.Webhook{URL: "https://example.com/hook", Secret: "0123456789abcdef", Orchestrator: "0xabc", JobType: "transcoding", Metric: "score", Condition: "below", Threshold: 0.5, DurationMinutes: 60},
+		},
+		{
+			name:           "AI webhook for a region",
+			body:           `{"url":"https://example.com/hook","secret":"0123456789abcdef","region":"fra","pipeline":"Text to image","model":"model1","metric":"success_rate","condition":"below","threshold":0.9,"duration_minutes":30}`,
+			expectedStatus: http.StatusCreated,
+			expectedHook:   &models.Webhook{URL: "https://example.com/hook", Secret: "0123456789abcdef", Region: "FRA", JobType: "ai", Pipeline: "Text to image", Model: "model1", Metric: "success_rate", Condition: "below", Threshold: 0.9, DurationMinutes: 30},
+		},
+		{
+			name:           "Missing threshold",
+			body:           `{"url":"https://example.com/hook","secret":"0123456789abcdef","metric":"score","condition":"below"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown metric",
+			body:           `{"url":"https://example.com/hook","secret":"0123456789abcdef","metric":"latency","condition":"below","threshold":0.5}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Not an http URL",
+			body:           `{"url":"ftp://example.com/hook","secret":"0123456789abcdef","metric":"score","condition":"below","threshold":0.5}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "AI webhook without a model",
+			body:           `{"url":"https://example.com/hook","secret":"0123456789abcdef","pipeline":"Text to image","metric":"score","condition":"below","threshold":0.5}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Logger.Info("Running test: %v", tt.name)
+			testutils.NewDB(t)
+
+			rr := serveWebhooks(t, WebhooksHandler, http.MethodPost, "/api/webhooks", []byte(tt.body))
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v, body: %s", status, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			var registered models.Webhook
+			if err := json.Unmarshal(rr.Body.Bytes(), &registered); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			if registered.ID == 0 || registered.CreatedAt == 0 {
+				t.Errorf("Expected the id and creation time of the webhook: %+v", registered)
+			}
+			tt.expectedHook.ID = registered.ID
+			tt.expectedHook.CreatedAt = registered.CreatedAt
+			if registered != *tt.expectedHook {
+				t.Errorf("Unexpected webhook: got %+v want %+v", registered, tt.expectedHook)
+			}
+
+			// the secret is not listed
+			rr = serveWebhooks(t, WebhooksHandler, http.MethodGet, "/api/webhooks", nil)
+			var listed map[string][]*models.Webhook
+			if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil {
+				t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+			}
+			if len(listed["webhooks"]) != 1 || listed["webhooks"][0].ID != registered.ID || listed["webhooks"][0].Secret != "" {
+				t.Errorf("Unexpected webhooks listed: %s", rr.Body.String())
+			}
+
+			path := "/api/webhooks?id=" + strconv.FormatInt(registered.ID, 10)
+			if rr = serveWebhooks(t, WebhooksHandler, http.MethodDelete, path, nil); rr.Code != http.StatusNoContent {
+				t.Errorf("Expected the webhook to be removed, got status %v", rr.Code)
+			}
+			if rr = serveWebhooks(t, WebhooksHandler, http.MethodDelete, path, nil); rr.Code != http.StatusNotFound {
+				t.Errorf("Expected a removed webhook to be unknown, got status %v", rr.Code)
+			}
+		})
+	}
+}
+
+func TestWebhooksHandlerUnauthorized(t *testing.T) {
+	os.Setenv("SECRET", "secret-key")
+	defer os.Unsetenv("SECRET")
+	testutils.NewDB(t)
+
+	now := time.Now()
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		timestamp time.Time
+		// sign overrides the request that is signed
+		sign func(r *http.Request) string
+	}{
+		{
+			name:      "Invalid signature",
+			method:    http.MethodGet,
+			path:      "/api/webhooks",
+			timestamp: now,
+			sign:      func(r *http.Request) string { return "invalid" },
+		},
+		{
+			name:      "HMAC of the empty body",
+			method:    http.MethodGet,
+			path:      "/api/webhooks",
+			timestamp: now,
+			sign:      func(r *http.Request) string { return auth.EncryptHeader(nil) },
+		},
+		{
+			name:      "Replayed request",
+			method:    http.MethodGet,
+			path:      "/api/webhooks",
+			timestamp: now.Add(-10 * time.Minute),
+		},
+		{
+			name:      "Signature of another webhook",
+			method:    http.MethodDelete,
+			path:      "/api/webhooks?id=2",
+			timestamp: now,
+			sign: func(r *http.Request) string {
+				signed := r.Clone(r.Context())
+				signed.URL.RawQuery = "id=1"
+				return auth.EncryptRequest(signed, nil)
+			},
+		},
+		{
+			name:      "Signature of another method",
+			method:    http.MethodDelete,
+			path:      "/api/webhooks?id=1",
+			timestamp: now,
+			sign: func(r *http.Request) string {
+				signed := r.Clone(r.Context())
+				signed.Method = http.MethodGet
+				return auth.EncryptRequest(signed, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set(auth.TimestampHeader, strconv.FormatInt(tt.timestamp.Unix(), 10))
+			if tt.sign != nil {
+				req.Header.Set("Authorization", tt.sign(req))
+			} else {
+				req.Header.Set("Authorization", auth.EncryptRequest(req, nil))
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(WebhooksHandler).ServeHTTP(rr, req)
+			if rr.Code != http.StatusForbidden {
+				t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestEvaluateWebhooksHandler(t *testing.T) {
+	os.Setenv("SECRET", "secret-key")
+	defer os.Unsetenv("SECRET")
+	t.Setenv("CRON_SECRET", "cron-secret")
+	testutils.NewDB(t)
+
+	// the failing AI stats score 0 so the webhook is triggered
+	failingStats := testutils.GetAIStats()
+	if err := db.Store.InsertStats(&failingStats); err != nil {
+		t.Fatalf("Unexpected error when inserting stats: %v", err)
+	}
+
+	received := make(chan models.WebhookEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(webhook.SignatureHeader) != auth.Sign("0123456789abcdef", body) {
+			t.Errorf("Unexpected signature %v", r.Header.Get(webhook.SignatureHeader))
+		}
+		var event models.WebhookEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("Failed to unmarshal the notification [%v]\n Body: %s", err, body)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"url":       server.URL,
+		"secret":    "0123456789abcdef",
+		"pipeline":  failingStats.Pipeline,
+		"model":     failingStats.Model,
+		"metric":    "score",
+		"condition": "below",
+		"threshold": 0.5,
+	})
+	if rr := serveWebhooks(t, WebhooksHandler, http.MethodPost, "/api/webhooks", body); rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to register the webhook: %s", rr.Body.String())
+	}
+
+	evaluate := func() []*models.WebhookDelivery {
+		req, err := http.NewRequest(http.MethodGet, "/api/evaluate_webhooks", nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer cron-secret")
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(EvaluateWebhooksHandler).ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var responseBody map[string][]*models.WebhookDelivery
+		if err := json.Unmarshal(rr.Body.Bytes(), &responseBody); err != nil {
+			t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+		}
+		return responseBody["deliveries"]
+	}
+
+	deliveries := evaluate()
+	if len(deliveries) != 1 || deliveries[0].Status != models.WebhookDeliveryDelivered {
+		t.Fatalf("Expected a single delivered notification, got %+v", deliveries)
+	}
+	event := <-received
+	if event.Event != models.WebhookEventTriggered || event.Orchestrator != failingStats.Orchestrator || event.Region != failingStats.Region {
+		t.Errorf("Unexpected notification: %+v", event)
+	}
+
+	// nothing changed so nothing is sent again
+	if deliveries := evaluate(); len(deliveries) != 0 {
+		t.Errorf("Expected no deliveries without a transition, got %+v", deliveries)
+	}
+
+	rr := serveWebhooks(t, WebhookDeliveriesHandler, http.MethodGet, "/api/webhook_deliveries?webhook_id="+strconv.FormatInt(deliveries[0].WebhookID, 10), nil)
+	var history map[string][]*models.WebhookDelivery
+	if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+		t.Fatalf("Failed to unmarshal response body [%v]\n Body: %s", err, rr.Body.String())
+	}
+	if len(history["deliveries"]) != 1 || history["deliveries"][0].Attempts != 1 {
+		t.Errorf("Unexpected delivery history: %s", rr.Body.String())
+	}
+}
+
+// serveWebhooks sends a request authenticated with the HMAC of the request and its body to the handler
+func serveWebhooks(t *testing.T, handler http.HandlerFunc, method, path string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set(auth.TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set("Authorization", auth.EncryptRequest(req, body))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
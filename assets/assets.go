@@ -15,3 +15,11 @@ const Path = "migrations"
 func GetMigrations() fs.FS {
 	return MigrationFiles
 }
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// GetOpenAPISpec returns the embedded OpenAPI document describing the API.
+func GetOpenAPISpec() []byte {
+	return openAPISpec
+}
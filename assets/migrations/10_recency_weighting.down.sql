@@ -0,0 +1 @@
+ALTER TABLE scoring_configs DROP COLUMN IF EXISTS half_life_minutes;
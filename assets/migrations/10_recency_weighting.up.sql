@@ -0,0 +1,2 @@
+-- the age at which a test counts half as much as a new one when stats are aggregated, 0 counts every test equally
+ALTER TABLE scoring_configs ADD COLUMN half_life_minutes INTEGER NOT NULL DEFAULT 0;
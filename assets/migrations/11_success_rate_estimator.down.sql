@@ -0,0 +1 @@
+ALTER TABLE scoring_configs DROP COLUMN IF EXISTS success_rate_estimator;
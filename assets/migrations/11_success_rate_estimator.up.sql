@@ -0,0 +1,3 @@
+-- how the success rate is estimated in the total score, 'mean' uses the average and 'wilson' the lower bound of
+-- its 95% Wilson score interval so that orchestrators with few tests do not outrank those with many
+ALTER TABLE scoring_configs ADD COLUMN success_rate_estimator VARCHAR(56) NOT NULL DEFAULT 'mean';
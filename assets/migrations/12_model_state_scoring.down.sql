@@ -0,0 +1,2 @@
+ALTER TABLE scoring_configs DROP COLUMN IF EXISTS warm_weight_pct;
+ALTER TABLE scoring_configs DROP COLUMN IF EXISTS model_state;
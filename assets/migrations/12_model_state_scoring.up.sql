@@ -0,0 +1,7 @@
+-- which AI tests the scores are based on, 'blend' uses the tests run against warm and cold models, 'warm' and
+-- 'cold' only those run against a model in that state
+ALTER TABLE scoring_configs ADD COLUMN model_state VARCHAR(56) NOT NULL DEFAULT 'blend'
+    CHECK (model_state IN ('blend', 'warm', 'cold'));
+-- the weight of warm models in percent in a 'blend' score, the warm and cold tests are weighted by their number
+-- when it is NULL
+ALTER TABLE scoring_configs ADD COLUMN warm_weight_pct INTEGER CHECK (warm_weight_pct BETWEEN 0 AND 100);
@@ -0,0 +1,20 @@
+DROP VIEW IF EXISTS event_details;
+
+CREATE VIEW event_details AS
+SELECT r.name AS region_name,
+    j.name AS job_type_name,
+    e.id,
+    e.event_time,
+    CAST(e.payload->>'success_rate' as FLOAT) AS success_rate,
+    CAST(e.payload->>'seg_duration' as FLOAT) AS seg_duration,
+    CAST(e.payload->>'round_trip_time' as FLOAT) AS round_trip_time,
+    e.orchestrator,
+    e.payload
+FROM events e
+        INNER JOIN
+    regions r ON e.region_id = r.id
+        INNER JOIN
+    job_types j ON r.job_type_id = j.id;
+
+ALTER TABLE events DROP COLUMN IF EXISTS timestamp_flagged;
+ALTER TABLE events DROP COLUMN IF EXISTS received_time;
@@ -0,0 +1,24 @@
+-- Keep track of when an event was received in addition to the time the tester reported it
+ALTER TABLE events ADD COLUMN received_time TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE events ADD COLUMN timestamp_flagged BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Existing events were always stored with the time they were received
+UPDATE events SET received_time = event_time;
+
+CREATE OR REPLACE VIEW event_details AS
+SELECT r.name AS region_name,
+    j.name AS job_type_name,
+    e.id,
+    e.event_time,
+    CAST(e.payload->>'success_rate' as FLOAT) AS success_rate,
+    CAST(e.payload->>'seg_duration' as FLOAT) AS seg_duration,
+    CAST(e.payload->>'round_trip_time' as FLOAT) AS round_trip_time,
+    e.orchestrator,
+    e.payload,
+    e.received_time,
+    e.timestamp_flagged
+FROM events e
+        INNER JOIN
+    regions r ON e.region_id = r.id
+        INNER JOIN
+    job_types j ON r.job_type_id = j.id;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS events_notify_new_event ON events;
+DROP FUNCTION IF EXISTS notify_new_event();
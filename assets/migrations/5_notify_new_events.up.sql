@@ -0,0 +1,12 @@
+-- Notify listeners of every new event so that all API instances can stream new test results.
+-- Only the id is sent as NOTIFY payloads are limited in size, listeners read the event by id.
+CREATE OR REPLACE FUNCTION notify_new_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('new_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_notify_new_event
+    AFTER INSERT ON events
+    FOR EACH ROW EXECUTE FUNCTION notify_new_event();
@@ -0,0 +1,22 @@
+-- Anomalies found by the detector when comparing orchestrators with their own baseline and the network
+CREATE TABLE anomalies
+(
+    id           SERIAL PRIMARY KEY,
+    detected_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end   TIMESTAMPTZ NOT NULL,
+    type         VARCHAR(56) NOT NULL,
+    metric       VARCHAR(56) NOT NULL,
+    orchestrator VARCHAR(56) NOT NULL,
+    region       VARCHAR(56) NOT NULL,
+    job_type     VARCHAR(56) NOT NULL,
+    pipeline     VARCHAR(128) NOT NULL DEFAULT '',
+    model        VARCHAR(256) NOT NULL DEFAULT '',
+    observed     DOUBLE PRECISION NOT NULL,
+    expected     DOUBLE PRECISION NOT NULL,
+    details      TEXT NOT NULL DEFAULT ''
+);
+
+-- the detector may run more than once for the same window, each anomaly is only recorded once
+CREATE UNIQUE INDEX idx_anomalies_unique ON anomalies (orchestrator, region, job_type, pipeline, model, type, metric, window_end);
+CREATE INDEX idx_anomalies_window_end ON anomalies (window_end);
@@ -0,0 +1,47 @@
+-- Webhooks notified when the metric of an orchestrator crosses a threshold
+CREATE TABLE webhooks
+(
+    id               SERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    url              TEXT NOT NULL,
+    secret           TEXT NOT NULL,
+    orchestrator     VARCHAR(56) NOT NULL DEFAULT '',
+    region           VARCHAR(56) NOT NULL DEFAULT '',
+    job_type         VARCHAR(56) NOT NULL,
+    pipeline         VARCHAR(128) NOT NULL DEFAULT '',
+    model            VARCHAR(256) NOT NULL DEFAULT '',
+    metric           VARCHAR(56) NOT NULL,
+    condition        VARCHAR(56) NOT NULL,
+    threshold        DOUBLE PRECISION NOT NULL,
+    duration_minutes INTEGER NOT NULL
+);
+
+-- whether each webhook is currently triggered for an orchestrator in a region, notifications are only sent when it changes
+CREATE TABLE webhook_states
+(
+    webhook_id   INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    orchestrator VARCHAR(56) NOT NULL,
+    region       VARCHAR(56) NOT NULL,
+    triggered    BOOLEAN NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (webhook_id, orchestrator, region)
+);
+
+-- every notification sent to a webhook and the result of the attempts to deliver it
+CREATE TABLE webhook_deliveries
+(
+    id              SERIAL PRIMARY KEY,
+    webhook_id      INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    event           VARCHAR(56) NOT NULL,
+    payload         JSONB NOT NULL,
+    status          VARCHAR(56) NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    status_code     INTEGER NOT NULL DEFAULT 0,
+    error           TEXT NOT NULL DEFAULT '',
+    last_attempt_at TIMESTAMPTZ,
+    next_attempt_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id);
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_events_orchestrator_event_time_id;
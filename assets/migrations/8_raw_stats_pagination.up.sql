@@ -0,0 +1,2 @@
+-- Raw stats of an orchestrator are paginated by event time and id
+CREATE INDEX idx_events_orchestrator_event_time_id ON events (orchestrator, event_time, id);
@@ -0,0 +1,22 @@
+-- Versions of the parameters stats are scored with, per job type and optionally per pipeline.  The version is
+-- returned with every score so consumers can tell when the methodology changed.
+CREATE TABLE scoring_configs
+(
+    version                 SERIAL PRIMARY KEY,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    job_type                VARCHAR(56) NOT NULL,
+    pipeline                VARCHAR(128) NOT NULL DEFAULT '',
+    scorer                  VARCHAR(56) NOT NULL DEFAULT '',
+    weight_success          DOUBLE PRECISION NOT NULL,
+    weight_rtt              DOUBLE PRECISION NOT NULL,
+    desired_score_at_median DOUBLE PRECISION NOT NULL,
+    active                  BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- a single version of a job type and pipeline is active at a time
+CREATE UNIQUE INDEX idx_scoring_configs_active ON scoring_configs (job_type, pipeline) WHERE active;
+
+-- the parameters stats were scored with before they were configurable
+INSERT INTO scoring_configs (job_type, weight_success, weight_rtt, desired_score_at_median, active)
+VALUES ('ai', 0.65, 0.35, 0.8, TRUE),
+       ('transcoding', 0.65, 0.35, 0.8, TRUE);
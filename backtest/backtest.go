@@ -0,0 +1,168 @@
+package backtest
+
+import (
+	"sort"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// Config holds the eligibility thresholds orchestrators are checked against
+type Config struct {
+	// TopN is the lowest rank an orchestrator is eligible with, 0 makes every rank eligible
+	TopN int
+	// MinScore is the lowest score an orchestrator is eligible with
+	MinScore float64
+}
+
+// eligible returns whether an entry passes both eligibility thresholds
+func (c Config) eligible(entry *models.LeaderboardEntry) bool {
+	return (c.TopN <= 0 || entry.Rank <= c.TopN) && entry.TotalScore >= c.MinScore
+}
+
+// RankChange compares an orchestrator in a region on the current and the candidate leaderboard
+type RankChange struct {
+	Orchestrator   string  `json:"orchestrator"`
+	Region         string  `json:"region"`
+	CurrentRank    int     `json:"current_rank"`
+	CandidateRank  int     `json:"candidate_rank"`
+	CurrentScore   float64 `json:"current_score"`
+	CandidateScore float64 `json:"candidate_score"`
+	// RankDelta is positive when the orchestrator moves up with the candidate configuration
+	RankDelta         int  `json:"rank_delta"`
+	CurrentEligible   bool `json:"current_eligible"`
+	CandidateEligible bool `json:"candidate_eligible"`
+}
+
+// Report compares the current and the candidate leaderboard of a job type or AI pipeline and model
+type Report struct {
+	JobType   string `json:"job_type"`
+	Pipeline  string `json:"pipeline,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Entries   int    `json:"entries"`
+	MovedUp   int    `json:"moved_up"`
+	MovedDown int    `json:"moved_down"`
+	// Spearman and Kendall are the rank correlations of both leaderboards, 1 when the rankings are the same and
+	// -1 when one is the reverse of the other
+	Spearman float64 `json:"spearman"`
+	Kendall  float64 `json:"kendall"`
+	// Changes are the entries whose rank changed, largest change first
+	Changes []*RankChange `json:"changes"`
+	// Crossings are the entries that become eligible or ineligible with the candidate configuration
+	Crossings []*RankChange `json:"crossings"`
+}
+
+// Compare matches the entries of the current and the candidate leaderboard of the same stats and reports how the
+// ranks changed.  Entries that are only on one of the leaderboards are left out.
+func Compare(current, candidate []*models.LeaderboardEntry, config Config) *Report {
+	candidateByKey := make(map[string]*models.LeaderboardEntry, len(candidate))
+	for _, entry := range candidate {
+		candidateByKey[entryKey(entry)] = entry
+	}
+
+	report := &Report{Changes: []*RankChange{}, Crossings: []*RankChange{}}
+	changes := []*RankChange{}
+	for _, currentEntry := range current {
+		candidateEntry, ok := candidateByKey[entryKey(currentEntry)]
+		if !ok {
+			continue
+		}
+		changes = append(changes, &RankChange{
+			Orchestrator:      currentEntry.Orchestrator,
+			Region:            currentEntry.Region,
+			CurrentRank:       currentEntry.Rank,
+			CandidateRank:     candidateEntry.Rank,
+			CurrentScore:      currentEntry.TotalScore,
+			CandidateScore:    candidateEntry.TotalScore,
+			RankDelta:         currentEntry.Rank - candidateEntry.Rank,
+			CurrentEligible:   config.eligible(currentEntry),
+			CandidateEligible: config.eligible(candidateEntry),
+		})
+	}
+	report.Entries = len(changes)
+	report.Spearman, report.Kendall = spearman(changes), kendall(changes)
+
+	for _, change := range changes {
+		switch {
+		case change.RankDelta > 0:
+			report.MovedUp++
+		case change.RankDelta < 0:
+			report.MovedDown++
+		}
+		if change.RankDelta != 0 {
+			report.Changes = append(report.Changes, change)
+		}
+		if change.CurrentEligible != change.CandidateEligible {
+			report.Crossings = append(report.Crossings, change)
+		}
+	}
+	sort.SliceStable(report.Changes, func(i, j int) bool {
+		return abs(report.Changes[i].RankDelta) > abs(report.Changes[j].RankDelta)
+	})
+	return report
+}
+
+// spearman returns the Spearman rank correlation.  The ranks are unique, so it is calculated from the differences
+// of the ranks among the matched entries.
+func spearman(changes []*RankChange) float64 {
+	n := len(changes)
+	if n < 2 {
+		return 1
+	}
+	currentRanks, candidateRanks := relativeRanks(changes)
+	sumSquares := 0.0
+	for i := range changes {
+		d := float64(currentRanks[i] - candidateRanks[i])
+		sumSquares += d * d
+	}
+	return 1 - 6*sumSquares/float64(n*(n*n-1))
+}
+
+// kendall returns the Kendall rank correlation, the share of concordant pairs minus the share of discordant pairs
+func kendall(changes []*RankChange) float64 {
+	n := len(changes)
+	if n < 2 {
+		return 1
+	}
+	concordant, discordant := 0, 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			current := changes[i].CurrentRank - changes[j].CurrentRank
+			candidate := changes[i].CandidateRank - changes[j].CandidateRank
+			if (current < 0) == (candidate < 0) {
+				concordant++
+			} else {
+				discordant++
+			}
+		}
+	}
+	return float64(concordant-discordant) / float64(n*(n-1)/2)
+}
+
+// relativeRanks re-ranks the matched entries from 1 to n on both leaderboards, so that entries left out of the
+// comparison do not leave gaps in the ranks
+func relativeRanks(changes []*RankChange) ([]int, []int) {
+	rank := func(value func(*RankChange) int) []int {
+		order := make([]int, len(changes))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return value(changes[order[a]]) < value(changes[order[b]]) })
+		ranks := make([]int, len(changes))
+		for r, i := range order {
+			ranks[i] = r + 1
+		}
+		return ranks
+	}
+	return rank(func(c *RankChange) int { return c.CurrentRank }), rank(func(c *RankChange) int { return c.CandidateRank })
+}
+
+func entryKey(entry *models.LeaderboardEntry) string {
+	return entry.Orchestrator + "/" + entry.Region + "/" + entry.Pipeline + "/" + entry.Model
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,122 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// newLeaderboard ranks the orchestrators in the given order, the scores decrease with the rank
+func newLeaderboard(orchestrators ...string) []*models.LeaderboardEntry {
+	entries := []*models.LeaderboardEntry{}
+	for i, orchestrator := range orchestrators {
+		entries = append(entries, &models.LeaderboardEntry{
+			Rank:         i + 1,
+			Orchestrator: orchestrator,
+			Region:       "FRA",
+			TotalScore:   1 - float64(i)/10,
+		})
+	}
+	return entries
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name              string
+		current           []*models.LeaderboardEntry
+		candidate         []*models.LeaderboardEntry
+		config            Config
+		expectedSpearman  float64
+		expectedKendall   float64
+		expectedMovedUp   int
+		expectedMovedDown int
+		expectedCrossings []string
+	}{
+		{
+			name:             "Same ranking",
+			current:          newLeaderboard("orch1", "orch2", "orch3"),
+			candidate:        newLeaderboard("orch1", "orch2", "orch3"),
+			expectedSpearman: 1,
+			expectedKendall:  1,
+		},
+		{
+			name:              "Reversed ranking",
+			current:           newLeaderboard("orch1", "orch2", "orch3"),
+			candidate:         newLeaderboard("orch3", "orch2", "orch1"),
+			config:            Config{TopN: 1},
+			expectedSpearman:  -1,
+			expectedKendall:   -1,
+			expectedMovedUp:   1,
+			expectedMovedDown: 1,
+			expectedCrossings: []string{"orch1", "orch3"},
+		},
+		{
+			name:              "Two orchestrators swap",
+			current:           newLeaderboard("orch1", "orch2", "orch3", "orch4"),
+			candidate:         newLeaderboard("orch2", "orch1", "orch3", "orch4"),
+			config:            Config{MinScore: 0.75},
+			expectedSpearman:  0.8,
+			expectedKendall:   2.0 / 3,
+			expectedMovedUp:   1,
+			expectedMovedDown: 1,
+			expectedCrossings: []string{},
+		},
+		{
+			name:              "Orchestrator only on one leaderboard",
+			current:           newLeaderboard("orch1", "orch2", "orch3"),
+			candidate:         newLeaderboard("orch2", "orch3"),
+			config:            Config{MinScore: 0.95},
+			expectedSpearman:  1,
+			expectedKendall:   1,
+			expectedMovedUp:   2,
+			expectedCrossings: []string{"orch2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Compare(tt.current, tt.candidate, tt.config)
+			if math.Abs(report.Spearman-tt.expectedSpearman) > 1e-9 || math.Abs(report.Kendall-tt.expectedKendall) > 1e-9 {
+				t.Errorf("Unexpected correlations: got %v and %v want %v and %v", report.Spearman, report.Kendall, tt.expectedSpearman, tt.expectedKendall)
+			}
+			if report.MovedUp != tt.expectedMovedUp || report.MovedDown != tt.expectedMovedDown || len(report.Changes) != tt.expectedMovedUp+tt.expectedMovedDown {
+				t.Errorf("Unexpected rank changes: %d up, %d down, %d changes", report.MovedUp, report.MovedDown, len(report.Changes))
+			}
+			if len(report.Crossings) != len(tt.expectedCrossings) {
+				t.Fatalf("Unexpected crossings: got %d want %v", len(report.Crossings), tt.expectedCrossings)
+			}
+			for i, crossing := range report.Crossings {
+				if crossing.Orchestrator != tt.expectedCrossings[i] || crossing.CurrentEligible == crossing.CandidateEligible {
+					t.Errorf("Unexpected crossing %+v, want %v", crossing, tt.expectedCrossings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompareOrdersChangesByRankDelta(t *testing.T) {
+	report := Compare(newLeaderboard("orch1", "orch2", "orch3", "orch4"), newLeaderboard("orch4", "orch1", "orch2", "orch3"), Config{})
+	if len(report.Changes) != 4 || report.Changes[0].Orchestrator != "orch4" || report.Changes[0].RankDelta != 3 {
+		t.Errorf("Expected the largest rank change first, got %+v", report.Changes[0])
+	}
+}
+
+func TestCandidateConfigs(t *testing.T) {
+	active := []*models.ScoringConfig{
+		{Version: 1, JobType: "ai"},
+		{Version: 2, JobType: "transcoding"},
+		{Version: 3, JobType: "ai", Pipeline: "Text to image"},
+	}
+	candidate := &models.ScoringConfig{JobType: "ai", WeightSuccess: 0.9, WeightRTT: 0.1}
+
+	configs := CandidateConfigs(active, candidate)
+	if len(configs) != 3 {
+		t.Fatalf("Expected the candidate to replace the active configuration of its job type, got %d configurations", len(configs))
+	}
+	if config := models.ActiveScoringConfig(configs, "ai", "Image to image"); config != candidate {
+		t.Errorf("Expected the candidate for the job type, got %+v", config)
+	}
+	if config := models.ActiveScoringConfig(configs, "ai", "Text to image"); config.Version != 3 {
+		t.Errorf("Expected the configuration of the pipeline to be kept, got %+v", config)
+	}
+}
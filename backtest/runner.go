@@ -0,0 +1,109 @@
+package backtest
+
+import (
+	"slices"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+)
+
+// Run replays the events of the query and compares the leaderboards scored with the active scoring configurations
+// with those scored with the candidate configuration.  It returns a report for the job type of the candidate or,
+// for AI, for every pipeline and model the candidate applies to.
+func Run(query *models.StatsQuery, candidate *models.ScoringConfig, config Config) ([]*Report, error) {
+	active, err := db.Store.ScoringConfigs()
+	if err != nil {
+		return nil, err
+	}
+	configs := CandidateConfigs(active, candidate)
+
+	queries, err := candidateQueries(query, candidate)
+	if err != nil {
+		return nil, err
+	}
+
+	sortField := models.NewSortField(models.LeaderboardSortScore, models.SortOrderDesc)
+	reports := []*Report{}
+	for _, currentQuery := range queries {
+		currentResults, err := db.Store.AggregatedStats(currentQuery)
+		if err != nil {
+			return nil, err
+		}
+		if !currentResults.HasResults() {
+			continue
+		}
+
+		// the candidate half-life changes how the events are aggregated
+		candidateQuery := *currentQuery
+		candidateQuery.HalfLifeMinutes = &candidate.HalfLifeMinutes
+		candidateResults, err := db.Store.AggregatedStats(&candidateQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		report := Compare(score.CreateLeaderboard(currentResults, sortField), score.CreateLeaderboardWithConfigs(candidateResults, sortField, configs), config)
+		report.JobType = currentQuery.JobType.String()
+		if len(currentQuery.Pipelines) == 1 && len(currentQuery.Models) == 1 {
+			report.Pipeline, report.Model = currentQuery.Pipelines[0], currentQuery.Models[0]
+		}
+		common.Logger.Debug("Backtested %d entries of %v %v %v", report.Entries, report.JobType, report.Pipeline, report.Model)
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// CandidateConfigs returns the active scoring configurations with the candidate in place of the one of the same job
+// type and pipeline, the same as when the candidate is activated
+func CandidateConfigs(active []*models.ScoringConfig, candidate *models.ScoringConfig) []*models.ScoringConfig {
+	configs := []*models.ScoringConfig{candidate}
+	for _, config := range active {
+		if config.JobType == candidate.JobType && config.Pipeline == candidate.Pipeline {
+			continue
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// candidateQueries splits the query into one query per leaderboard the candidate applies to.  AI stats are ranked
+// per pipeline and model, restricted to the pipeline of the candidate and those of the query when given.
+func candidateQueries(query *models.StatsQuery, candidate *models.ScoringConfig) ([]*models.StatsQuery, error) {
+	jobType, err := models.JobTypeFromString(candidate.JobType)
+	if err != nil {
+		return nil, err
+	}
+	if jobType != models.AI {
+		transcodingQuery := *query
+		transcodingQuery.JobType = jobType
+		transcodingQuery.Pipelines, transcodingQuery.Models = nil, nil
+		return []*models.StatsQuery{&transcodingQuery}, nil
+	}
+
+	pipelines, err := db.Store.Pipelines(&models.StatsQuery{Since: query.Since, Until: query.Until})
+	if err != nil {
+		return nil, err
+	}
+	queries := []*models.StatsQuery{}
+	for _, pipeline := range pipelines {
+		if candidate.Pipeline != "" && pipeline.Name != candidate.Pipeline || !matches(query.Pipelines, pipeline.Name) {
+			continue
+		}
+		for _, model := range pipeline.Models {
+			if !matches(query.Models, model) {
+				continue
+			}
+			aiQuery := *query
+			aiQuery.JobType = models.AI
+			aiQuery.Pipelines, aiQuery.Models = []string{pipeline.Name}, []string{model}
+			queries = append(queries, &aiQuery)
+		}
+	}
+	return queries, nil
+}
+
+// matches returns whether the value is one of the values, any value matches when there are none
+func matches(values []string, value string) bool {
+	return len(values) == 0 || slices.Contains(values, value)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/backtest"
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+)
+
+// backtest replays the events stored in the database over a period and reports how the rankings would change if a
+// candidate scoring configuration was activated.  The candidate starts from the active configuration of the job
+// type and pipeline, only the parameters given as flags are changed.
+func main() {
+	since := flag.String("since", "", "start of the period as RFC 3339, defaults to START_TIME_WINDOW hours before the end")
+	until := flag.String("until", "", "end of the period as RFC 3339, defaults to now")
+	jobType := flag.String("job_type", models.AI.String(), "job type of the candidate, 'ai' or 'transcoding'")
+	pipeline := flag.String("pipeline", "", "AI pipeline of the candidate, empty for every pipeline of the job type")
+	model := flag.String("model", "", "only replay the AI stats of this model")
+	minSamples := flag.Int("min_samples", 0, "leave out the stats of fewer tests")
+	topN := flag.Int("top", 10, "lowest rank an orchestrator is eligible with, 0 makes every rank eligible")
+	minScore := flag.Float64("min_score", 0, "lowest score an orchestrator is eligible with")
+	format := flag.String("format", "text", "output format, 'text' or 'json'")
+
+	scorer := flag.String("scorer", "", "candidate scoring strategy")
+	weightSuccess := flag.Float64("weight_success", 0, "candidate weight of the success rate")
+	weightRTT := flag.Float64("weight_rtt", 0, "candidate weight of the RTT score")
+	desiredScoreAtMedian := flag.Float64("desired_score_at_median", 0, "candidate RTT score at the median RTT")
+	halfLifeMinutes := flag.Int("half_life_minutes", 0, "candidate half-life of the tests, 0 weights them equally")
+	successRateEstimator := flag.String("success_rate_estimator", "", "candidate success rate estimator, 'mean' or 'wilson'")
+	modelState := flag.String("model_state", "", "candidate model state AI scores are based on, 'blend', 'warm' or 'cold'")
+	warmWeightPct := flag.Int("warm_weight_pct", -1, "candidate weight of warm models in percent in a blend, negative weights the tests by their number")
+	flag.Parse()
+
+	common.Logger.SetLevel(common.EnvOrDefault("LOG_LEVEL", "warn").(string))
+
+	query, err := parseQuery(*since, *until, *pipeline, *model, *minSamples)
+	if err != nil {
+		exit(err)
+	}
+	if err := db.CacheDB(); err != nil {
+		exit(err)
+	}
+	active, err := db.Store.ScoringConfigs()
+	if err != nil {
+		exit(err)
+	}
+
+	// the candidate is a copy of the active configuration with the parameters of the flags that were given
+	candidate := score.DefaultScoringConfig(*jobType)
+	if config := models.ActiveScoringConfig(active, *jobType, *pipeline); config != nil {
+		*candidate = *config
+	}
+	candidate.Version = 0
+	candidate.Pipeline = *pipeline
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "scorer":
+			candidate.Scorer = *scorer
+		case "weight_success":
+			candidate.WeightSuccess = *weightSuccess
+		case "weight_rtt":
+			candidate.WeightRTT = *weightRTT
+		case "desired_score_at_median":
+			candidate.DesiredScoreAtMedian = *desiredScoreAtMedian
+		case "half_life_minutes":
+			candidate.HalfLifeMinutes = *halfLifeMinutes
+		case "success_rate_estimator":
+			candidate.SuccessRateEstimator = *successRateEstimator
+		case "model_state":
+			candidate.ModelState = *modelState
+		case "warm_weight_pct":
+			candidate.WarmWeightPct = nil
+			if *warmWeightPct >= 0 {
+				candidate.WarmWeightPct = warmWeightPct
+			}
+		}
+	})
+
+	if err := score.ValidateScoringConfig(candidate); err != nil {
+		exit(err)
+	}
+
+	reports, err := backtest.Run(query, candidate, backtest.Config{TopN: *topN, MinScore: *minScore})
+	if err != nil {
+		exit(err)
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(reports)
+	case "text":
+		err = writeReports(os.Stdout, candidate, reports)
+	default:
+		err = fmt.Errorf("format must be 'text' or 'json'")
+	}
+	if err != nil {
+		exit(err)
+	}
+	db.Store.Close()
+}
+
+// parseQuery returns the query of the period and filters given as flags
+func parseQuery(since, until, pipeline, model string, minSamples int) (*models.StatsQuery, error) {
+	query := &models.StatsQuery{Until: time.Now().UTC(), MinSamples: minSamples}
+	if until != "" {
+		parsed, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, fmt.Errorf("until must be an RFC 3339 timestamp: %w", err)
+		}
+		query.Until = parsed.UTC()
+	}
+	query.Since = query.Until.Add(-time.Duration(common.EnvOrDefault("START_TIME_WINDOW", 24).(int)) * time.Hour)
+	if since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, fmt.Errorf("since must be an RFC 3339 timestamp: %w", err)
+		}
+		query.Since = parsed.UTC()
+	}
+	if !query.Since.Before(query.Until) {
+		return nil, fmt.Errorf("since must be before until")
+	}
+	if pipeline != "" {
+		query.Pipelines = []string{pipeline}
+	}
+	if model != "" {
+		query.Models = []string{model}
+	}
+	return query, nil
+}
+
+// writeReports writes a summary of every leaderboard followed by its rank changes and eligibility crossings
+func writeReports(w io.Writer, candidate *models.ScoringConfig, reports []*backtest.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	warmWeightPct := "by number of tests"
+	if candidate.WarmWeightPct != nil {
+		warmWeightPct = fmt.Sprint(*candidate.WarmWeightPct)
+	}
+	fmt.Fprintf(tw, "Candidate: job type %v, pipeline %q, scorer %q, weight_success %v, weight_rtt %v, desired_score_at_median %v, half_life_minutes %v, success_rate_estimator %q, model_state %q, warm_weight_pct %v\n",
+		candidate.JobType, candidate.Pipeline, candidate.Scorer, candidate.WeightSuccess, candidate.WeightRTT, candidate.DesiredScoreAtMedian, candidate.HalfLifeMinutes, candidate.SuccessRateEstimator, candidate.ModelState, warmWeightPct)
+	if len(reports) == 0 {
+		fmt.Fprintln(tw, "No stats found to backtest")
+	}
+	for _, report := range reports {
+		fmt.Fprintf(tw, "\n%v %v %v\n", report.JobType, report.Pipeline, report.Model)
+		fmt.Fprintf(tw, "Entries: %d, moved up: %d, moved down: %d, Spearman: %.4f, Kendall: %.4f\n",
+			report.Entries, report.MovedUp, report.MovedDown, report.Spearman, report.Kendall)
+		if len(report.Changes) > 0 {
+			fmt.Fprintln(tw, "ORCHESTRATOR\tREGION\tRANK\tCANDIDATE RANK\tDELTA\tSCORE\tCANDIDATE SCORE")
+			for _, change := range report.Changes {
+				fmt.Fprintf(tw, "%v\t%v\t%d\t%d\t%+d\t%.4f\t%.4f\n", change.Orchestrator, change.Region,
+					change.CurrentRank, change.CandidateRank, change.RankDelta, change.CurrentScore, change.CandidateScore)
+			}
+		}
+		for _, crossing := range report.Crossings {
+			verb := "becomes ineligible"
+			if crossing.CandidateEligible {
+				verb = "becomes eligible"
+			}
+			fmt.Fprintf(tw, "%v in %v %v (rank %d to %d, score %.4f to %.4f)\n", crossing.Orchestrator, crossing.Region, verb,
+				crossing.CurrentRank, crossing.CandidateRank, crossing.CurrentScore, crossing.CandidateScore)
+		}
+	}
+	return tw.Flush()
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "backtest: %v\n", err)
+	os.Exit(1)
+}
@@ -1,10 +1,14 @@
 package common
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strconv"
+
+	"github.com/livepeer/leaderboard-serverless/models"
 )
 
 func HandleInternalError(w http.ResponseWriter, err error) {
@@ -15,6 +19,24 @@ func HandleBadRequest(w http.ResponseWriter, err error) {
 	RespondWithError(w, err, http.StatusBadRequest)
 }
 
+// HandleValidationError responds with a 400 listing every problem found while validating the request
+// and falls back to a plain bad request for any other error.
+func HandleValidationError(w http.ResponseWriter, err error) {
+	var validationErrs models.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		HandleBadRequest(w, err)
+		return
+	}
+	Logger.Warn("The user request failed validation: %v", err.Error())
+	body, _ := json.Marshal(map[string]interface{}{
+		"error":   models.ErrRequestValidation.Error(),
+		"details": validationErrs,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(body)
+}
+
 func RespondWithError(w http.ResponseWriter, err error, code int) {
 	Logger.Warn("An error occured while handling the user request: %v", err.Error())
 	http.Error(w, fmt.Sprintf("{\"error\":\"%s\"}", err.Error()), code)
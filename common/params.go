@@ -1,8 +1,12 @@
 package common
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"math"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -10,13 +14,15 @@ import (
 	"github.com/livepeer/leaderboard-serverless/models"
 )
 
-// ParseStatsQueryParams parses and defaults the 'since', 'until', and 'region' parameters from the URL query.
+// ParseStatsQueryParams parses and defaults the 'since', 'until', 'region', 'orchestrator', 'pipeline', 'model' and
+// 'job_type' parameters from the URL query.  The orchestrators, regions, pipelines and models can be given as comma
+// separated values, by repeating the parameter or both.
 func ParseStatsQueryParams(r *http.Request) (*models.StatsQuery, error) {
 	queryParams := r.URL.Query()
 
-	orch := strings.ToLower(queryParams.Get("orchestrator"))
-	pipeline := queryParams.Get("pipeline")
-	model := queryParams.Get("model")
+	orchs := ParseListParam(queryParams, "orchestrator", strings.ToLower)
+	pipelines := ParseListParam(queryParams, "pipeline", nil)
+	aiModels := ParseListParam(queryParams, "model", nil)
 
 	// Parse 'Since' parameter
 	since, err := parseSince(r)
@@ -30,39 +36,78 @@ func ParseStatsQueryParams(r *http.Request) (*models.StatsQuery, error) {
 	}
 
 	// Parse 'Region' parameter
-	region := strings.ToUpper(queryParams.Get("region"))
+	regions := ParseListParam(queryParams, "region", strings.ToUpper)
 
-	jobTypeStr := queryParams.Get("job_type")
+	jobTypeStr := strings.ToLower(queryParams.Get("job_type"))
 	var finalJobType models.JobType
 	if jobTypeStr != "" {
 		jobTypeParsed, err := models.JobTypeFromString(jobTypeStr)
 		if err != nil {
-			Logger.Warn("Invalid job_type parameter: %v", queryParams.Get("job_type"))
+			return nil, models.ErrInvalidJobType
 		}
 		finalJobType = jobTypeParsed
 	}
 
 	// if model OR pipeline is supplied, thrown an error since both are required
-	if model != "" && pipeline == "" {
+	if len(aiModels) > 0 && len(pipelines) == 0 {
 		return nil, models.ErrMissingPipeline
 	}
-	if pipeline != "" && model == "" {
+	if len(pipelines) > 0 && len(aiModels) == 0 {
 		return nil, models.ErrMissingModel
 	}
 
-	Logger.Debug("Parsed query parameters: since=%v, until=%v, region=%v, orchestrator=%v, pipeline=%v, model=%v, jobType=%v", since, until, region, orch, pipeline, model, finalJobType)
+	// Parse 'half_life_minutes' parameter, the scoring configuration decides when it is not supplied
+	var halfLifeMinutes *int
+	if halfLifeStr := queryParams.Get("half_life_minutes"); halfLifeStr != "" {
+		minutes, err := strconv.Atoi(halfLifeStr)
+		if err != nil || minutes < 0 {
+			return nil, models.ErrInvalidHalfLife
+		}
+		halfLifeMinutes = &minutes
+	}
+
+	// Parse 'min_samples' parameter, stats of fewer tests are left out
+	minSamples := 0
+	if minSamplesStr := queryParams.Get("min_samples"); minSamplesStr != "" {
+		minSamples, err = strconv.Atoi(minSamplesStr)
+		if err != nil || minSamples < 0 {
+			return nil, models.ErrInvalidMinSamples
+		}
+	}
+
+	Logger.Debug("Parsed query parameters: since=%v, until=%v, regions=%v, orchestrators=%v, pipelines=%v, models=%v, jobType=%v", since, until, regions, orchs, pipelines, aiModels, finalJobType)
 
 	return &models.StatsQuery{
-		Since:        since,
-		Until:        until,
-		Region:       region,
-		Orchestrator: orch,
-		Pipeline:     pipeline,
-		Model:        model,
-		JobType:      finalJobType,
+		Since:           since,
+		Until:           until,
+		Regions:         regions,
+		Orchestrators:   orchs,
+		Pipelines:       pipelines,
+		Models:          aiModels,
+		JobType:         finalJobType,
+		HalfLifeMinutes: halfLifeMinutes,
+		MinSamples:      minSamples,
 	}, nil
 }
 
+// ParseListParam returns the distinct values of a parameter that can be repeated and hold comma separated values.
+// Empty values are ignored and every value is normalized when a normalize function is given.
+func ParseListParam(queryParams url.Values, name string, normalize func(string) string) []string {
+	var values []string
+	for _, param := range queryParams[name] {
+		for _, value := range strings.Split(param, ",") {
+			value = strings.TrimSpace(value)
+			if normalize != nil {
+				value = normalize(value)
+			}
+			if value != "" && !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
 func GetDefaultSince() time.Time {
 	startTimeWindow := EnvOrDefault("START_TIME_WINDOW", 24).(int)
 	return time.Now().Add(time.Duration(-startTimeWindow) * time.Hour).UTC()
@@ -107,3 +152,189 @@ func parseUntil(r *http.Request) (time.Time, error) {
 		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
 	}
 }
+
+// DefaultPageLimit is the number of items returned when no 'limit' parameter is supplied
+const DefaultPageLimit = 100
+
+// MaxPageLimit is the largest 'limit' parameter a caller may request
+const MaxPageLimit = 1000
+
+// ParsePageQueryParams parses and defaults the 'limit' and 'offset' parameters from the URL query.
+func ParsePageQueryParams(r *http.Request) (*models.PageQuery, error) {
+	queryParams := r.URL.Query()
+
+	page := &models.PageQuery{Limit: DefaultPageLimit}
+
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return nil, models.ErrInvalidLimit
+		}
+		page.Limit = min(limit, MaxPageLimit)
+	}
+
+	if offsetStr := queryParams.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return nil, models.ErrInvalidOffset
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// ParseLeaderboardSortParams parses and defaults the 'sort' and 'order' parameters from the URL query.
+// Results are sorted by score in descending order unless specified otherwise.
+func ParseLeaderboardSortParams(r *http.Request) (models.StatsQuerySortField, error) {
+	queryParams := r.URL.Query()
+
+	field := strings.ToLower(queryParams.Get("sort"))
+	if field == "" {
+		field = models.LeaderboardSortScore
+	}
+	if !models.IsValidLeaderboardSortField(field) {
+		return models.StatsQuerySortField{}, models.ErrInvalidSort
+	}
+
+	// text fields read naturally in ascending order, scores in descending order
+	order := models.SortOrderDesc
+	if field == models.LeaderboardSortOrchestrator || field == models.LeaderboardSortRegion {
+		order = models.SortOrderAsc
+	}
+	switch strings.ToLower(queryParams.Get("order")) {
+	case "":
+	case "asc":
+		order = models.SortOrderAsc
+	case "desc":
+		order = models.SortOrderDesc
+	default:
+		return models.StatsQuerySortField{}, models.ErrInvalidOrder
+	}
+
+	return models.NewSortField(field, order), nil
+}
+
+// ParseRawStatsPageParams parses the 'limit', 'sort', 'order' and 'cursor' parameters of raw stats into the query.
+// Raw stats are sorted by timestamp in descending order unless specified otherwise.  The limit is only set when
+// it is supplied.  A cursor must have been returned for the same sort.
+func ParseRawStatsPageParams(r *http.Request, query *models.StatsQuery) error {
+	queryParams := r.URL.Query()
+
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return models.ErrInvalidLimit
+		}
+		query.Limit = min(limit, MaxPageLimit)
+	}
+
+	field := strings.ToLower(queryParams.Get("sort"))
+	if field == "" {
+		field = models.RawStatsSortTimestamp
+	}
+	if !models.IsValidRawStatsSortField(field) {
+		return models.ErrInvalidSort
+	}
+	order := models.SortOrderDesc
+	switch strings.ToLower(queryParams.Get("order")) {
+	case "":
+	case "asc":
+		order = models.SortOrderAsc
+	case "desc":
+		order = models.SortOrderDesc
+	default:
+		return models.ErrInvalidOrder
+	}
+	query.SortFields = []models.StatsQuerySortField{models.NewSortField(field, order)}
+
+	if cursorStr := queryParams.Get("cursor"); cursorStr != "" {
+		cursor, err := DecodeRawStatsCursor(cursorStr)
+		if err != nil || cursor.Sort != field || cursor.Order != order {
+			return models.ErrInvalidCursor
+		}
+		query.After = cursor
+	}
+	return nil
+}
+
+// EncodeRawStatsCursor encodes the cursor as an opaque URL safe string
+func EncodeRawStatsCursor(cursor *models.RawStatsCursor) (string, error) {
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// DecodeRawStatsCursor decodes a cursor encoded by EncodeRawStatsCursor
+func DecodeRawStatsCursor(cursorStr string) (*models.RawStatsCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursorStr)
+	if err != nil {
+		return nil, err
+	}
+	var cursor models.RawStatsCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// HistoryBuckets are the supported bucket sizes for time-bucketed queries
+var HistoryBuckets = map[string]time.Duration{
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// DefaultHistoryBucket is the bucket used when no 'bucket' parameter is supplied
+const DefaultHistoryBucket = "1h"
+
+// MaxHistoryBuckets is the largest number of buckets a single request may span
+const MaxHistoryBuckets = 2016
+
+// ParseBucketParam parses and defaults the 'bucket' parameter from the URL query and
+// ensures the requested time window does not span too many buckets.
+func ParseBucketParam(r *http.Request, query *models.StatsQuery) (string, time.Duration, error) {
+	bucket := strings.ToLower(r.URL.Query().Get("bucket"))
+	if bucket == "" {
+		bucket = DefaultHistoryBucket
+	}
+	bucketSize, ok := HistoryBuckets[bucket]
+	if !ok {
+		return "", 0, models.ErrInvalidBucket
+	}
+	if query.Until.Sub(query.Since)/bucketSize > MaxHistoryBuckets {
+		return "", 0, models.ErrTooManyBuckets
+	}
+	return bucket, bucketSize, nil
+}
+
+// Formats a response can be exported in
+const (
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ParseFormatParam determines the response format from the 'format' parameter of the URL query
+// or, when it is not supplied, from the Accept header.  JSON is used by default.
+func ParseFormatParam(r *http.Request) (string, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	switch format {
+	case FormatJSON, FormatCSV, FormatNDJSON:
+		return format, nil
+	case "":
+	default:
+		return "", models.ErrInvalidFormat
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return FormatCSV, nil
+	case strings.Contains(accept, "application/x-ndjson"), strings.Contains(accept, "application/ndjson"):
+		return FormatNDJSON, nil
+	}
+	return FormatJSON, nil
+}
@@ -0,0 +1,198 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+func TestParseStatsQueryParamsMultipleValues(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/aggregated_stats?orchestrator=0xABC,0xdef&orchestrator=0xabc&region=fra,%20mdw&region=&pipeline=Text%20to%20image&model=model1,model2", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	query, err := ParseStatsQueryParams(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(query.Orchestrators, []string{"0xabc", "0xdef"}) {
+		t.Errorf("Unexpected orchestrators: %v", query.Orchestrators)
+	}
+	if !reflect.DeepEqual(query.Regions, []string{"FRA", "MDW"}) {
+		t.Errorf("Unexpected regions: %v", query.Regions)
+	}
+	if !reflect.DeepEqual(query.Pipelines, []string{"Text to image"}) || !reflect.DeepEqual(query.Models, []string{"model1", "model2"}) {
+		t.Errorf("Unexpected pipelines %v and models %v", query.Pipelines, query.Models)
+	}
+	if err := query.CanScore(); !errors.Is(err, models.ErrMultiplePipelines) {
+		t.Errorf("Expected several models not to be scored together, got %v", err)
+	}
+
+	req, err = http.NewRequest(http.MethodGet, "/api/aggregated_stats?model=model1,model2", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if _, err := ParseStatsQueryParams(req); !errors.Is(err, models.ErrMissingPipeline) {
+		t.Errorf("Expected models to require a pipeline, got %v", err)
+	}
+}
+
+func TestParseStatsQueryParamsHalfLife(t *testing.T) {
+	tests := []struct {
+		params      string
+		expected    *int
+		expectedErr error
+	}{
+		{params: ""},
+		{params: "half_life_minutes=0", expected: new(int)},
+		{params: "half_life_minutes=90", expected: func() *int { minutes := 90; return &minutes }()},
+		{params: "half_life_minutes=-5", expectedErr: models.ErrInvalidHalfLife},
+		{params: "half_life_minutes=1h", expectedErr: models.ErrInvalidHalfLife},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, "/api/aggregated_stats?"+tt.params, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		query, err := ParseStatsQueryParams(req)
+		if !errors.Is(err, tt.expectedErr) {
+			t.Fatalf("Unexpected error for %q: got %v want %v", tt.params, err, tt.expectedErr)
+		}
+		if tt.expectedErr != nil {
+			continue
+		}
+		if !reflect.DeepEqual(query.HalfLifeMinutes, tt.expected) {
+			t.Errorf("Unexpected half-life for %q: got %v want %v", tt.params, query.HalfLifeMinutes, tt.expected)
+		}
+	}
+}
+
+func TestParseStatsQueryParamsMinSamples(t *testing.T) {
+	tests := []struct {
+		params      string
+		expected    int
+		expectedErr error
+	}{
+		{params: ""},
+		{params: "min_samples=0"},
+		{params: "min_samples=20", expected: 20},
+		{params: "min_samples=-1", expectedErr: models.ErrInvalidMinSamples},
+		{params: "min_samples=many", expectedErr: models.ErrInvalidMinSamples},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, "/api/aggregated_stats?"+tt.params, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		query, err := ParseStatsQueryParams(req)
+		if !errors.Is(err, tt.expectedErr) {
+			t.Fatalf("Unexpected error for %q: got %v want %v", tt.params, err, tt.expectedErr)
+		}
+		if tt.expectedErr != nil {
+			continue
+		}
+		if query.MinSamples != tt.expected {
+			t.Errorf("Unexpected min samples for %q: got %v want %v", tt.params, query.MinSamples, tt.expected)
+		}
+	}
+}
+
+func TestParseRawStatsPageParams(t *testing.T) {
+	cursor, err := EncodeRawStatsCursor(&models.RawStatsCursor{
+		Sort:          models.RawStatsSortRoundTripTime,
+		Order:         models.SortOrderAsc,
+		EventTime:     time.Date(2024, 9, 20, 12, 0, 0, 123456000, time.UTC),
+		RoundTripTime: 0.25,
+		ID:            42,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error when encoding the cursor: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		params        string
+		expectedLimit int
+		expectedSort  models.StatsQuerySortField
+		expectedAfter *models.RawStatsCursor
+		expectedErr   error
+	}{
+		{
+			name:         "Defaults",
+			params:       "",
+			expectedSort: models.NewSortField(models.RawStatsSortTimestamp, models.SortOrderDesc),
+		},
+		{
+			name:          "Limit is capped",
+			params:        "limit=5000&sort=round_trip_time&order=asc",
+			expectedLimit: MaxPageLimit,
+			expectedSort:  models.NewSortField(models.RawStatsSortRoundTripTime, models.SortOrderAsc),
+		},
+		{
+			name:          "Cursor of the same sort",
+			params:        "sort=round_trip_time&order=asc&cursor=" + cursor,
+			expectedSort:  models.NewSortField(models.RawStatsSortRoundTripTime, models.SortOrderAsc),
+			expectedAfter: &models.RawStatsCursor{Sort: models.RawStatsSortRoundTripTime, Order: models.SortOrderAsc, EventTime: time.Date(2024, 9, 20, 12, 0, 0, 123456000, time.UTC), RoundTripTime: 0.25, ID: 42},
+		},
+		{
+			name:        "Cursor of a different sort",
+			params:      "cursor=" + cursor,
+			expectedErr: models.ErrInvalidCursor,
+		},
+		{
+			name:        "Malformed cursor",
+			params:      "cursor=not-a-cursor",
+			expectedErr: models.ErrInvalidCursor,
+		},
+		{
+			name:        "Unknown sort field",
+			params:      "sort=score",
+			expectedErr: models.ErrInvalidSort,
+		},
+		{
+			name:        "Invalid limit",
+			params:      "limit=0",
+			expectedErr: models.ErrInvalidLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/api/raw_stats?"+tt.params, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			query := &models.StatsQuery{}
+			err = ParseRawStatsPageParams(req, query)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Unexpected error: got %v want %v", err, tt.expectedErr)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+			if query.Limit != tt.expectedLimit {
+				t.Errorf("Unexpected limit: got %v want %v", query.Limit, tt.expectedLimit)
+			}
+			if len(query.SortFields) != 1 || query.SortFields[0] != tt.expectedSort {
+				t.Errorf("Unexpected sort: got %v want %v", query.SortFields, tt.expectedSort)
+			}
+			if tt.expectedAfter == nil {
+				if query.After != nil {
+					t.Errorf("Unexpected cursor: %+v", query.After)
+				}
+				return
+			}
+			if query.After == nil || !query.After.EventTime.Equal(tt.expectedAfter.EventTime) ||
+				query.After.Sort != tt.expectedAfter.Sort || query.After.Order != tt.expectedAfter.Order ||
+				query.After.RoundTripTime != tt.expectedAfter.RoundTripTime || query.After.ID != tt.expectedAfter.ID {
+				t.Errorf("Unexpected cursor: got %+v want %+v", query.After, tt.expectedAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"strings"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// Policies for reported timestamps that fall outside of the allowed skew
+const (
+	TimestampSkewPolicyReject = "reject"
+	TimestampSkewPolicyFlag   = "flag"
+)
+
+// timestampPastTolerance is how far in the past a reported timestamp may be
+var timestampPastTolerance = time.Duration(EnvOrDefault("TIMESTAMP_PAST_TOLERANCE", 86400).(int)) * time.Second
+
+// timestampFutureTolerance is how far in the future a reported timestamp may be
+var timestampFutureTolerance = time.Duration(EnvOrDefault("TIMESTAMP_FUTURE_TOLERANCE", 300).(int)) * time.Second
+
+// timestampSkewPolicy decides whether timestamps outside the tolerance are rejected or flagged
+var timestampSkewPolicy = strings.ToLower(EnvOrDefault("TIMESTAMP_SKEW_POLICY", TimestampSkewPolicyReject).(string))
+
+// ResolveEventTime determines the time an event is recorded at.  The timestamp reported by the tester
+// (in unix seconds) is used when it is within the configured tolerance of the time the event was received.
+// When no timestamp was reported the received time is used.  Timestamps outside of the tolerance
+// are either rejected or recorded at the received time and flagged, depending on TIMESTAMP_SKEW_POLICY.
+func ResolveEventTime(reported int64, received time.Time) (time.Time, bool, error) {
+	if reported <= 0 {
+		return received, false, nil
+	}
+
+	reportedTime := time.Unix(reported, 0).UTC()
+	if !reportedTime.Before(received.Add(-timestampPastTolerance)) && !reportedTime.After(received.Add(timestampFutureTolerance)) {
+		return reportedTime, false, nil
+	}
+
+	if timestampSkewPolicy == TimestampSkewPolicyFlag {
+		Logger.Debug("Reported timestamp %v is outside of the allowed skew from %v and will be flagged", reportedTime, received)
+		return received, true, nil
+	}
+	return received, false, models.ErrTimestampOutOfRange
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+func TestResolveEventTime(t *testing.T) {
+	received := time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		reported          int64
+		policy            string
+		expectedEventTime time.Time
+		expectedFlagged   bool
+		expectedErr       error
+	}{
+		{
+			name:              "No reported timestamp",
+			reported:          0,
+			policy:            TimestampSkewPolicyReject,
+			expectedEventTime: received,
+		},
+		{
+			name:              "Reported timestamp within tolerance",
+			reported:          received.Add(-2 * time.Hour).Unix(),
+			policy:            TimestampSkewPolicyReject,
+			expectedEventTime: received.Add(-2 * time.Hour),
+		},
+		{
+			name:              "Reported timestamp too far in the past is rejected",
+			reported:          received.Add(-48 * time.Hour).Unix(),
+			policy:            TimestampSkewPolicyReject,
+			expectedEventTime: received,
+			expectedErr:       models.ErrTimestampOutOfRange,
+		},
+		{
+			name:              "Reported timestamp too far in the future is flagged",
+			reported:          received.Add(time.Hour).Unix(),
+			policy:            TimestampSkewPolicyFlag,
+			expectedEventTime: received,
+			expectedFlagged:   true,
+		},
+	}
+
+	defaultPolicy := timestampSkewPolicy
+	defer func() { timestampSkewPolicy = defaultPolicy }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestampSkewPolicy = tt.policy
+			eventTime, flagged, err := ResolveEventTime(tt.reported, received)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("unexpected error: got %v want %v", err, tt.expectedErr)
+			}
+			if !eventTime.Equal(tt.expectedEventTime) {
+				t.Errorf("unexpected event time: got %v want %v", eventTime, tt.expectedEventTime)
+			}
+			if flagged != tt.expectedFlagged {
+				t.Errorf("unexpected flag: got %v want %v", flagged, tt.expectedFlagged)
+			}
+		})
+	}
+}
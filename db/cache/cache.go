@@ -16,6 +16,9 @@ type Cache interface {
 	InvalidatePipelinesCache()
 	GetPipelines() CacheResult
 	UpdatePipelines(newPipelines []*models.Pipeline)
+	InvalidateScoringConfigsCache()
+	GetScoringConfigs() CacheResult
+	UpdateScoringConfigs(newScoringConfigs []*models.ScoringConfig)
 }
 
 type CacheResult struct {
@@ -33,12 +36,16 @@ type MemCache struct {
 	pipelinesCacheTimeout time.Duration
 	pipelines             []*models.Pipeline
 	pipelinesLastUpdate   time.Time
+	scoringConfigsTimeout time.Duration
+	scoringConfigs        []*models.ScoringConfig
+	scoringConfigsUpdate  time.Time
 }
 
 func NewCache() *MemCache {
 	c := &MemCache{}
 	c.regionsCacheTimeout = getCacheTimeout("REGIONS_CACHE_TIMEOUT", 60)
 	c.pipelinesCacheTimeout = getCacheTimeout("PIPELINES_CACHE_TIMEOUT", 60)
+	c.scoringConfigsTimeout = getCacheTimeout("SCORING_CONFIGS_CACHE_TIMEOUT", 60)
 	return c
 }
 
@@ -76,6 +83,20 @@ func (c *MemCache) UpdatePipelines(newPipelines []*models.Pipeline) {
 	c.updateCache(&c.pipelines, &c.pipelinesLastUpdate, newPipelines)
 }
 
+func (c *MemCache) InvalidateScoringConfigsCache() {
+	common.Logger.Debug("Invalidating scoring configs cache")
+	c.invalidateCacheResult(c.scoringConfigs)
+}
+
+func (c *MemCache) GetScoringConfigs() CacheResult {
+	return c.getCacheResult(c.scoringConfigs, c.scoringConfigsUpdate, c.scoringConfigsTimeout)
+}
+
+func (c *MemCache) UpdateScoringConfigs(newScoringConfigs []*models.ScoringConfig) {
+	common.Logger.Debug("Updating scoring configs cache")
+	c.updateCache(&c.scoringConfigs, &c.scoringConfigsUpdate, newScoringConfigs)
+}
+
 /** Utility functions **/
 
 func (c *MemCache) invalidateCacheResult(data interface{}) {
@@ -91,6 +112,10 @@ func (c *MemCache) invalidateCacheResult(data interface{}) {
 		c.pipelines = nil
 		c.pipelinesLastUpdate = zeroTime
 		common.Logger.Debug("Invalidated pipelines cache")
+	case []*models.ScoringConfig:
+		c.scoringConfigs = nil
+		c.scoringConfigsUpdate = zeroTime
+		common.Logger.Debug("Invalidated scoring configs cache")
 	default:
 		common.Logger.Warn("unsupported cache type: %T", v)
 	}
@@ -117,6 +142,8 @@ func (c *MemCache) updateCache(data interface{}, lastUpdate *time.Time, newData
 		c.regions = newData.([]*models.Region)
 	case *[]*models.Pipeline:
 		c.pipelines = newData.([]*models.Pipeline)
+	case *[]*models.ScoringConfig:
+		c.scoringConfigs = newData.([]*models.ScoringConfig)
 	default:
 		common.Logger.Warn("unsupported cache type: %T", v)
 	}
@@ -32,6 +32,7 @@ func TestMain(m *testing.M) {
 func setCacheTimeouts(length string) {
 	os.Setenv("REGIONS_CACHE_TIMEOUT", length)
 	os.Setenv("PIPELINES_CACHE_TIMEOUT", length)
+	os.Setenv("SCORING_CONFIGS_CACHE_TIMEOUT", length)
 }
 
 func TestInvalidateRegionsCache(t *testing.T) {
@@ -117,6 +118,29 @@ func TestUpdateRegions(t *testing.T) {
 	}
 }
 
+func TestScoringConfigsCache(t *testing.T) {
+	cache := NewCache()
+	testConfig := &models.ScoringConfig{Version: 3, JobType: models.AI.String(), WeightSuccess: 0.5, WeightRTT: 0.5, DesiredScoreAtMedian: 0.8, Active: true}
+	cache.UpdateScoringConfigs([]*models.ScoringConfig{testConfig})
+
+	cacheResult := cache.GetScoringConfigs()
+	if !cacheResult.CacheHit || cacheResult.CacheExpired {
+		t.Fatalf("expected a cache hit that is not expired, got %+v", cacheResult)
+	}
+	if configs := cacheResult.Results.([]*models.ScoringConfig); len(configs) != 1 || *configs[0] != *testConfig {
+		t.Errorf("expected the cached scoring config, got %v", configs)
+	}
+
+	cache.InvalidateScoringConfigsCache()
+	cacheResult = cache.GetScoringConfigs()
+	if cacheResult.Results != nil && len(cacheResult.Results.([]*models.ScoringConfig)) > 0 {
+		t.Errorf("expected nil scoring configs, got %v", cacheResult.Results)
+	}
+	if !cacheResult.LastUpdate.IsZero() {
+		t.Errorf("expected zero LastUpdate, got %v", cacheResult.LastUpdate)
+	}
+}
+
 func TestInvalidatePipelinesCache(t *testing.T) {
 	cache := NewCache()
 	cache.UpdatePipelines([]*models.Pipeline{{Name: "test-pipeline"}})
@@ -1,16 +1,43 @@
 package interfaces
 
-import "github.com/livepeer/leaderboard-serverless/models"
+import (
+	"context"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
 
 type DB interface {
 	InsertStats(stats *models.Stats) error
+	InsertStatsBatch(stats []*models.Stats) error
+	ListenStats(ctx context.Context, fn func(id int64, stats *models.Stats)) error
 	AggregatedStats(query *models.StatsQuery) (*models.AggregatedStatsResults, error)
+	AggregatedStatsByModel(query *models.StatsQuery) ([]*models.AggregatedStatsResults, error)
 	MedianRTT(query *models.StatsQuery) (float64, error)
+	StatsHistory(query *models.StatsQuery, bucketSize time.Duration) ([]*models.StatsBucket, error)
 	BestAIRegion(orchestratorId string) (*models.Stats, error)
+	BestAIRegions(orchestratorId string) ([]*models.Stats, error)
 	RawStats(query *models.StatsQuery) ([]*models.Stats, error)
+	EachRawStats(ctx context.Context, query *models.StatsQuery, fn func(stat *models.Stats, cursor *models.RawStatsCursor) error) error
+	ErrorCounts(query *models.StatsQuery) ([]*models.ErrorCount, error)
+	OrchestratorActivity(orchestratorId string, since time.Time) ([]*models.OrchestratorActivity, error)
+	RegionHealth(query *models.StatsQuery) ([]*models.RegionHealth, error)
+	InsertAnomalies(anomalies []*models.Anomaly) (int, error)
+	Anomalies(query *models.StatsQuery, anomalyType string) ([]*models.Anomaly, error)
+	InsertWebhook(webhook *models.Webhook) error
+	Webhooks() ([]*models.Webhook, error)
+	DeleteWebhook(id int64) (bool, error)
+	WebhookStates(webhookID int64) ([]*models.WebhookState, error)
+	InsertWebhookDeliveries(deliveries []*models.WebhookDelivery, states []*models.WebhookState) error
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) error
+	DueWebhookDeliveries(now time.Time) ([]*models.WebhookDelivery, error)
+	WebhookDeliveries(webhookID int64, limit int) ([]*models.WebhookDelivery, error)
+	LatencyPercentiles(query *models.StatsQuery) ([]*models.OrchestratorLatency, error)
+	NetworkLatencyPercentiles(query *models.StatsQuery) (*models.LatencyPercentiles, error)
 	Regions() ([]*models.Region, error)
 	InsertRegions(regions []*models.Region) (int, int)
 	Pipelines(query *models.StatsQuery) ([]*models.Pipeline, error)
+	ScoringConfigs() ([]*models.ScoringConfig, error)
 	Close()
 }
 
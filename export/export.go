@@ -0,0 +1,199 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// flushInterval is the number of rows written before the response is flushed to the client
+const flushInterval = 100
+
+// Record is a single row of an export
+type Record interface {
+	// Row returns the CSV columns of the record in the same order as the header of the export
+	Row() []string
+}
+
+// Writer writes records to an HTTP response as CSV or NDJSON.  Nothing is written to the
+// response until the first record is written (or the writer is closed), so callers can
+// still respond with an error if the records could not be retrieved.
+type Writer struct {
+	w       http.ResponseWriter
+	format  string
+	name    string
+	header  []string
+	csv     *csv.Writer
+	started bool
+	rows    int
+}
+
+// NewWriter creates a writer for the given format.  The name is used as the file name of CSV downloads.
+func NewWriter(w http.ResponseWriter, format string, name string, header []string) *Writer {
+	return &Writer{w: w, format: format, name: name, header: header}
+}
+
+// Write writes a single record
+func (ew *Writer) Write(record Record) error {
+	if err := ew.start(); err != nil {
+		return err
+	}
+
+	if ew.format == common.FormatCSV {
+		if err := ew.csv.Write(record.Row()); err != nil {
+			return err
+		}
+	} else {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := ew.w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	ew.rows++
+	if ew.rows%flushInterval == 0 {
+		ew.flush()
+	}
+	return nil
+}
+
+// Close writes any buffered rows, including the CSV header when no records were written
+func (ew *Writer) Close() error {
+	if err := ew.start(); err != nil {
+		return err
+	}
+	ew.flush()
+	if ew.csv != nil {
+		return ew.csv.Error()
+	}
+	return nil
+}
+
+// start writes the response headers and the CSV header the first time it is called
+func (ew *Writer) start() error {
+	if ew.started {
+		return nil
+	}
+	ew.started = true
+
+	if ew.format == common.FormatCSV {
+		ew.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		ew.w.Header().Set("Content-Disposition", "attachment; filename=\""+ew.name+".csv\"")
+	} else {
+		ew.w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	ew.w.WriteHeader(http.StatusOK)
+
+	if ew.format == common.FormatCSV {
+		ew.csv = csv.NewWriter(ew.w)
+		return ew.csv.Write(ew.header)
+	}
+	return nil
+}
+
+func (ew *Writer) flush() {
+	if ew.csv != nil {
+		ew.csv.Flush()
+	}
+	if flusher, ok := ew.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// RawStatsHeader are the CSV columns of a raw stats export
+var RawStatsHeader = []string{
+	"region", "orchestrator", "timestamp", "success_rate", "round_trip_time",
+	"seg_duration", "segments_sent", "segments_received", "upload_time", "download_time", "transcode_time",
+	"pipeline", "model", "model_is_warm", "input_parameters", "response_payload", "errors",
+}
+
+// RawStatsRecord is a raw stats object flattened into stable columns
+type RawStatsRecord struct {
+	*models.Stats
+}
+
+func (r RawStatsRecord) Row() []string {
+	return []string{
+		r.Region,
+		r.Orchestrator,
+		strconv.FormatInt(r.Timestamp, 10),
+		formatFloat(r.SuccessRate),
+		formatFloat(r.RoundTripTime),
+		formatFloat(r.SegDuration),
+		strconv.Itoa(r.SegmentsSent),
+		strconv.Itoa(r.SegmentsReceived),
+		formatFloat(r.UploadTime),
+		formatFloat(r.DownloadTime),
+		formatFloat(r.TranscodeTime),
+		r.Pipeline,
+		r.Model,
+		strconv.FormatBool(r.ModelIsWarm),
+		r.InputParameters,
+		r.ResponsePayload,
+		formatJSON(r.Errors),
+	}
+}
+
+// AggregatedStatsHeader are the CSV columns of an aggregated stats export
+var AggregatedStatsHeader = []string{"orchestrator", "region", "success_rate", "round_trip_score", "score", "score_version", "tests", "errors"}
+
+// AggregatedStatsRecord is the aggregated stats of an orchestrator in a region
+type AggregatedStatsRecord struct {
+	Orchestrator string `json:"orchestrator"`
+	Region       string `json:"region"`
+	*models.AggregatedStats
+}
+
+func (r AggregatedStatsRecord) Row() []string {
+	return []string{
+		r.Orchestrator,
+		r.Region,
+		formatFloat(r.SuccessRate),
+		formatFloat(r.RoundTripScore),
+		formatFloat(r.TotalScore),
+		strconv.FormatInt(r.ScoreVersion, 10),
+		strconv.Itoa(r.Tests),
+		formatJSON(r.Errors),
+	}
+}
+
+// AggregatedStatsRecords flattens the aggregated stats into records ordered by orchestrator and region
+func AggregatedStatsRecords(results map[string]map[string]*models.AggregatedStats) []AggregatedStatsRecord {
+	records := []AggregatedStatsRecord{}
+	for orchestrator, regions := range results {
+		for region, aggrStats := range regions {
+			records = append(records, AggregatedStatsRecord{Orchestrator: orchestrator, Region: region, AggregatedStats: aggrStats})
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Orchestrator != records[j].Orchestrator {
+			return records[i].Orchestrator < records[j].Orchestrator
+		}
+		return records[i].Region < records[j].Region
+	})
+	return records
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatJSON encodes nested values such as errors as JSON so they fit in a single column
+func formatJSON(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil || string(encoded) == "null" {
+		return ""
+	}
+	return string(encoded)
+}
@@ -0,0 +1,101 @@
+package export
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+func TestWriter(t *testing.T) {
+	aggregatedStats := map[string]map[string]*models.AggregatedStats{
+		"orch2": {
+			"MDW": {SuccessRate: 1, RoundTripScore: 0.5, TotalScore: 0.5, ScoreVersion: 2, Tests: 3},
+		},
+		"orch1": {
+			"MDW": {SuccessRate: 0.5, RoundTripScore: 0.25, TotalScore: 0.125, ScoreVersion: 2, Tests: 4, Errors: map[string]int{"timeout": 2}},
+			"FRA": {SuccessRate: 1, RoundTripScore: 1, TotalScore: 1, ScoreVersion: 2, Tests: 1},
+		},
+	}
+
+	tests := []struct {
+		name                string
+		format              string
+		records             []AggregatedStatsRecord
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "CSV",
+			format:              common.FormatCSV,
+			records:             AggregatedStatsRecords(aggregatedStats),
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: "orchestrator,region,success_rate,round_trip_score,score,score_version,tests,errors\n" +
+				"orch1,FRA,1,1,1,2,1,\n" +
+				"orch1,MDW,0.5,0.25,0.125,2,4,\"{\"\"timeout\"\":2}\"\n" +
+				"orch2,MDW,1,0.5,0.5,2,3,\n",
+		},
+		{
+			name:                "CSV without records",
+			format:              common.FormatCSV,
+			records:             nil,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "orchestrator,region,success_rate,round_trip_score,score,score_version,tests,errors\n",
+		},
+		{
+			name:                "NDJSON",
+			format:              common.FormatNDJSON,
+			records:             AggregatedStatsRecords(aggregatedStats)[:2],
+			expectedContentType: "application/x-ndjson",
+			expectedBody: `{"orchestrator":"orch1","region":"FRA","success_rate":1,"round_trip_score":1,"score":1,"score_version":2,"tests":1}` + "\n" +
+				`{"orchestrator":"orch1","region":"MDW","success_rate":0.5,"round_trip_score":0.25,"score":0.125,"score_version":2,"errors":{"timeout":2},"tests":4}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			writer := NewWriter(rr, tt.format, "aggregated_stats", AggregatedStatsHeader)
+			for _, record := range tt.records {
+				if err := writer.Write(record); err != nil {
+					t.Fatalf("unexpected error writing record: %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("unexpected error closing writer: %v", err)
+			}
+
+			if contentType := rr.Header().Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Errorf("unexpected content type: got %v want %v", contentType, tt.expectedContentType)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("unexpected body: got %q want %q", rr.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestRawStatsRecordRow(t *testing.T) {
+	stats := &models.Stats{
+		Region:       "MDW",
+		Orchestrator: "orch1",
+		SuccessRate:  1,
+		Timestamp:    1726864722,
+		Pipeline:     "text-to-image",
+		Model:        "some/model",
+		ModelIsWarm:  true,
+		Errors:       []models.Error{{ErrorCode: "HTTP-STATUS-503", Count: 1}},
+	}
+
+	row := RawStatsRecord{Stats: stats}.Row()
+	if len(row) != len(RawStatsHeader) {
+		t.Fatalf("row has %d columns but the header has %d", len(row), len(RawStatsHeader))
+	}
+	expected := []string{"MDW", "orch1", "1726864722", "1", "0", "0", "0", "0", "0", "0", "0", "text-to-image", "some/model", "true", "", "", `[{"error_code":"HTTP-STATUS-503","count":1}]`}
+	for i := range expected {
+		if row[i] != expected[i] {
+			t.Errorf("unexpected value for column %s: got %q want %q", RawStatsHeader[i], row[i], expected[i])
+		}
+	}
+}
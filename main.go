@@ -14,9 +14,24 @@ func main() {
 	http.HandleFunc("/api/raw_stats", handler.RawStatsHandler)
 	http.HandleFunc("/api/aggregated_stats", handler.AggregatedStatsHandler)
 	http.HandleFunc("/api/top_ai_score", handler.TopAiScoreHandler)
+	http.HandleFunc("/api/top_ai_scores", handler.TopAiScoresHandler)
 	http.HandleFunc("/api/post_stats", handler.PostStatsHandler)
 	http.HandleFunc("/api/pipelines", handler.PipelinesHandler)
 	http.HandleFunc("/api/regions", handler.RegionsHandler)
+	http.HandleFunc("/api/leaderboard", handler.LeaderboardHandler)
+	http.HandleFunc("/api/score_history", handler.ScoreHistoryHandler)
+	http.HandleFunc("/api/score_explanation", handler.ScoreExplanationHandler)
+	http.HandleFunc("/api/errors", handler.ErrorsHandler)
+	http.HandleFunc("/api/latency", handler.LatencyHandler)
+	http.HandleFunc("/api/region_health", handler.RegionHealthHandler)
+	http.HandleFunc("/api/anomalies", handler.AnomaliesHandler)
+	http.HandleFunc("/api/detect_anomalies", handler.DetectAnomaliesHandler)
+	http.HandleFunc("/api/webhooks", handler.WebhooksHandler)
+	http.HandleFunc("/api/webhook_deliveries", handler.WebhookDeliveriesHandler)
+	http.HandleFunc("/api/evaluate_webhooks", handler.EvaluateWebhooksHandler)
+	http.HandleFunc("/api/orchestrators/", handler.OrchestratorHandler)
+	http.HandleFunc("/api/stream_stats", handler.StreamStatsHandler)
+	http.HandleFunc("/api/openapi", handler.OpenAPIHandler)
 
 	common.Logger.Info("Server starting on port 8080")
 
@@ -3,17 +3,63 @@ package auth
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 )
 
+// TimestampHeader holds the unix time in seconds a signed request was sent at
+const TimestampHeader = "X-Leaderboard-Timestamp"
+
+// requestTolerance is how far the timestamp of a signed request may be from the time it is received
+const requestTolerance = 5 * time.Minute
+
 func IsAuthorized(authHeader string, body []byte) bool {
 
 	return EncryptHeader(body) == authHeader
 }
 
 func EncryptHeader(body []byte) string {
-	hash := hmac.New(sha256.New, []byte(os.Getenv("SECRET")))
+	return Sign(os.Getenv("SECRET"), body)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body using the secret
+func Sign(secret string, body []byte) string {
+	hash := hmac.New(sha256.New, []byte(secret))
 	hash.Write(body)
 	return hex.EncodeToString(hash.Sum(nil))
 }
+
+// IsRequestAuthorized checks the Authorization header of a request against the HMAC of its method, path, query,
+// timestamp and body using the SECRET.  Requests sent more than five minutes before or after now are rejected, so a
+// captured request can not be replayed later, nor be sent to another path or with another query.
+func IsRequestAuthorized(r *http.Request, body []byte, now time.Time) bool {
+	timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(timestamp, 0)); skew > requestTolerance || skew < -requestTolerance {
+		return false
+	}
+	return hmac.Equal([]byte(EncryptRequest(r, body)), []byte(r.Header.Get("Authorization")))
+}
+
+// EncryptRequest returns the hex encoded HMAC-SHA256 of the method, path, raw query, timestamp header and body of
+// the request, each on its own line, using the SECRET
+func EncryptRequest(r *http.Request, body []byte) string {
+	message := r.Method + "\n" + r.URL.Path + "\n" + r.URL.RawQuery + "\n" + r.Header.Get(TimestampHeader) + "\n" + string(body)
+	return Sign(os.Getenv("SECRET"), []byte(message))
+}
+
+// IsCronAuthorized checks the bearer token of a scheduled job against the CRON_SECRET.
+// Requests are rejected when no secret is configured.
+func IsCronAuthorized(authHeader string) bool {
+	secret := os.Getenv("CRON_SECRET")
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(authHeader), []byte("Bearer "+secret)) == 1
+}
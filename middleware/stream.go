@@ -0,0 +1,12 @@
+package middleware
+
+import "net/http"
+
+// AddStreamHttpHeaders sets the headers of a server-sent events response, which must never be cached
+func AddStreamHttpHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+}
@@ -0,0 +1,374 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/livepeer/leaderboard-serverless/assets"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+const (
+	parameterRefPrefix = "#/components/parameters/"
+	schemaRefPrefix    = "#/components/schemas/"
+)
+
+// Schema is the subset of an OpenAPI schema object used to validate requests
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Enum       []interface{}      `json:"enum"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	MinLength  *int               `json:"minLength"`
+	Nullable   bool               `json:"nullable"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+	Items      *Schema            `json:"items"`
+	OneOf      []*Schema          `json:"oneOf"`
+}
+
+// Parameter is an OpenAPI parameter object
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// Operation is an OpenAPI operation object
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+}
+
+// Spec is the subset of an OpenAPI document needed to validate requests
+type Spec struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Parameters map[string]*Parameter `json:"parameters"`
+		Schemas    map[string]*Schema    `json:"schemas"`
+	} `json:"components"`
+}
+
+// spec is the embedded OpenAPI document.  It is loaded once when the package is initialised
+// so that a broken document is noticed as soon as the service starts.
+var spec = mustLoadSpec(assets.GetOpenAPISpec())
+
+// LoadSpec parses an OpenAPI document and resolves the parameter references of every operation
+func LoadSpec(data []byte) (*Spec, error) {
+	var s Spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+
+	for path, item := range s.Paths {
+		for method, op := range item {
+			for i, param := range op.Parameters {
+				if param.Ref == "" {
+					continue
+				}
+				resolved, ok := s.Components.Parameters[strings.TrimPrefix(param.Ref, parameterRefPrefix)]
+				if !ok {
+					return nil, fmt.Errorf("%s %s: unresolved parameter reference %s", method, path, param.Ref)
+				}
+				op.Parameters[i] = resolved
+			}
+			for _, param := range op.Parameters {
+				if err := s.checkRefs(param.Schema); err != nil {
+					return nil, fmt.Errorf("%s %s: %w", method, path, err)
+				}
+			}
+		}
+	}
+	for _, schema := range s.Components.Schemas {
+		if err := s.checkRefs(schema); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+func mustLoadSpec(data []byte) *Spec {
+	s, err := LoadSpec(data)
+	if err != nil {
+		panic(fmt.Sprintf("invalid OpenAPI specification: %v", err))
+	}
+	return s
+}
+
+// checkRefs ensures every schema reference nested in the schema points to a known schema
+func (s *Spec) checkRefs(schema *Schema) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		if _, ok := s.Components.Schemas[strings.TrimPrefix(schema.Ref, schemaRefPrefix)]; !ok {
+			return fmt.Errorf("unresolved schema reference %s", schema.Ref)
+		}
+		return nil
+	}
+	for _, prop := range schema.Properties {
+		if err := s.checkRefs(prop); err != nil {
+			return err
+		}
+	}
+	for _, option := range schema.OneOf {
+		if err := s.checkRefs(option); err != nil {
+			return err
+		}
+	}
+	return s.checkRefs(schema.Items)
+}
+
+// ValidateQuery validates the query parameters of the request against the GET operation of the path,
+// e.g. "/api/leaderboard".  Empty parameters are treated as absent and unknown parameters are ignored.
+// A models.ValidationErrors is returned when any parameter is invalid.
+func ValidateQuery(r *http.Request, path string) error {
+	if errs := spec.ValidateQuery(path, r.URL.Query()); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateSchema validates a decoded JSON value against a named schema of the specification.
+// A models.ValidationErrors is returned when the value does not match the schema.
+func ValidateSchema(name string, value interface{}) error {
+	if errs := spec.ValidateSchema(name, value); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateQuery validates query parameters against the GET operation of the path.  Every value of a repeated
+// parameter and every element of a comma separated value is validated, a parameter is reported once.
+func (s *Spec) ValidateQuery(path string, query url.Values) models.ValidationErrors {
+	op, ok := s.Paths[path][strings.ToLower(http.MethodGet)]
+	if !ok {
+		return nil
+	}
+
+	errs := models.ValidationErrors{}
+	for _, param := range op.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		elements := queryElements(query[param.Name])
+		if len(elements) == 0 {
+			if param.Required {
+				errs = append(errs, queryError(param.Name, "is required"))
+			}
+			continue
+		}
+		for _, raw := range elements {
+			value, err := parseQueryValue(param.Schema, raw)
+			if err != nil {
+				errs = append(errs, queryError(param.Name, err.Error()))
+				break
+			}
+			found := len(errs)
+			s.validate(param.Schema, value, param.Name, "query", &errs)
+			if len(errs) > found {
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// queryElements returns the non-empty elements of the values of a query parameter, the same way the list parameters
+// are parsed
+func queryElements(values []string) []string {
+	elements := []string{}
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			if element = strings.TrimSpace(element); element != "" {
+				elements = append(elements, element)
+			}
+		}
+	}
+	return elements
+}
+
+// ValidateSchema validates a decoded JSON value against a named schema
+func (s *Spec) ValidateSchema(name string, value interface{}) models.ValidationErrors {
+	errs := models.ValidationErrors{}
+	s.validate(&Schema{Ref: schemaRefPrefix + name}, value, "", "body", &errs)
+	return errs
+}
+
+func queryError(field, message string) *models.ValidationError {
+	return &models.ValidationError{In: "query", Field: field, Message: message}
+}
+
+// parseQueryValue converts a raw query parameter to the JSON type its schema expects
+func parseQueryValue(schema *Schema, raw string) (interface{}, error) {
+	if schema == nil {
+		return raw, nil
+	}
+	switch schema.Type {
+	case "integer":
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return float64(v), nil
+	case "number":
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return v, nil
+	case "boolean":
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return v, nil
+	}
+	return raw, nil
+}
+
+func (s *Spec) validate(schema *Schema, value interface{}, field, in string, errs *models.ValidationErrors) {
+	if schema == nil {
+		return
+	}
+	if schema.Ref != "" {
+		s.validate(s.Components.Schemas[strings.TrimPrefix(schema.Ref, schemaRefPrefix)], value, field, in, errs)
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		name := field
+		if name == "" {
+			name = in
+		}
+		*errs = append(*errs, &models.ValidationError{In: in, Field: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		if !schema.Nullable && (schema.Type != "" || len(schema.OneOf) > 0) {
+			fail("must not be null")
+		}
+		return
+	}
+
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		for _, option := range schema.OneOf {
+			optionErrs := models.ValidationErrors{}
+			s.validate(option, value, field, in, &optionErrs)
+			if len(optionErrs) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			fail("must match exactly one of the allowed schemas")
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				*errs = append(*errs, &models.ValidationError{In: in, Field: joinField(field, name), Message: "is required"})
+			}
+		}
+		// properties are validated in name order so the reported errors are stable
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if v, ok := obj[name]; ok {
+				s.validate(schema.Properties[name], v, joinField(field, name), in, errs)
+			}
+		}
+		return
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		for i, item := range items {
+			s.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), in, errs)
+		}
+		return
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if schema.MinLength != nil && len(str) < *schema.MinLength {
+			fail("must be at least %d characters long", *schema.MinLength)
+		}
+	case "number", "integer":
+		num, ok := value.(float64)
+		if !ok {
+			fail("must be a %s", schema.Type)
+			return
+		}
+		if schema.Type == "integer" && num != math.Trunc(num) {
+			fail("must be an integer")
+			return
+		}
+		if schema.Minimum != nil && num < *schema.Minimum {
+			fail("must be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && num > *schema.Maximum {
+			fail("must be at most %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		allowed := make([]string, len(schema.Enum))
+		for i, e := range schema.Enum {
+			allowed[i] = fmt.Sprintf("'%v'", e)
+		}
+		fail("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// inEnum reports whether the value is one of the allowed values.  Strings are compared
+// case-insensitively as the handlers normalise the case of enumerated parameters.
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if a, ok := allowed.(string); ok {
+			if v, ok := value.(string); ok && strings.EqualFold(a, v) {
+				return true
+			}
+			continue
+		}
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
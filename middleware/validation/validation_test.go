@@ -0,0 +1,176 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+func TestSpecCoversEveryHandler(t *testing.T) {
+	// handlers served from a rewritten path
+	rewrites := map[string]string{
+		"/api/orchestrator": "/api/orchestrators/{address}",
+	}
+
+	files, err := filepath.Glob("../../api/*.go")
+	if err != nil {
+		t.Fatalf("Failed to list handlers: %v", err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		path := "/api/" + strings.TrimSuffix(filepath.Base(file), ".go")
+		if rewritten, ok := rewrites[path]; ok {
+			path = rewritten
+		}
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("The OpenAPI specification does not describe %s", path)
+		}
+	}
+}
+
+func TestLoadSpecUnresolvedRef(t *testing.T) {
+	doc := `{"paths":{"/api/x":{"get":{"parameters":[{"$ref":"#/components/parameters/Missing"}]}}}}`
+	if _, err := LoadSpec([]byte(doc)); err == nil {
+		t.Errorf("Expected an error for an unresolved parameter reference")
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		query          string
+		expectedFields []string
+	}{
+		{
+			name:  "Valid parameters",
+			path:  "/api/leaderboard",
+			query: "job_type=ai&since=1726833600.5&limit=10&offset=0&sort=success_rate&order=ASC",
+		},
+		{
+			name:  "Empty and unknown parameters are ignored",
+			path:  "/api/aggregated_stats",
+			query: "job_type=&foo=bar",
+		},
+		{
+			name:           "Invalid job type",
+			path:           "/api/aggregated_stats",
+			query:          "job_type=video",
+			expectedFields: []string{"job_type"},
+		},
+		{
+			name:           "Every invalid parameter is reported",
+			path:           "/api/leaderboard",
+			query:          "since=yesterday&limit=0&offset=1.5",
+			expectedFields: []string{"since", "limit", "offset"},
+		},
+		{
+			name:           "Every value of a repeated parameter is validated",
+			path:           "/api/aggregated_stats",
+			query:          "job_type=ai&job_type=video&min_samples=1&min_samples=-1",
+			expectedFields: []string{"job_type", "min_samples"},
+		},
+		{
+			name:           "Every element of a comma separated value is validated",
+			path:           "/api/leaderboard",
+			query:          "order=asc,sideways&limit=10,0,-1",
+			expectedFields: []string{"limit", "order"},
+		},
+		{
+			name:           "Missing required parameter",
+			path:           "/api/raw_stats",
+			query:          "region=FRA",
+			expectedFields: []string{"orchestrator"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{URL: &url.URL{Path: tt.path, RawQuery: tt.query}}
+			err := ValidateQuery(r, tt.path)
+			assertFields(t, err, tt.expectedFields)
+		})
+	}
+}
+
+func TestValidateStatsSchema(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedFields []string
+	}{
+		{
+			name: "Valid stats",
+			body: `{"region":"FRA","orchestrator":"0x1","success_rate":1,"round_trip_time":0.5,"errors":null,"timestamp":1726833600}`,
+		},
+		{
+			name: "Valid stats with errors",
+			body: `{"region":"FRA","orchestrator":"0x1","errors":[{"error_code":"timeout","count":2}]}`,
+		},
+		{
+			name:           "Missing required fields",
+			body:           `{"success_rate":1}`,
+			expectedFields: []string{"region", "orchestrator"},
+		},
+		{
+			name:           "Out of range and mistyped fields",
+			body:           `{"region":"FRA","orchestrator":"0x1","success_rate":1.5,"segments_sent":2.5,"model_is_warm":"yes"}`,
+			expectedFields: []string{"model_is_warm", "segments_sent", "success_rate"},
+		},
+		{
+			name:           "Invalid nested error",
+			body:           `{"region":"FRA","orchestrator":"0x1","errors":[{"count":-1}]}`,
+			expectedFields: []string{"errors[0].count", "errors[0].error_code"},
+		},
+		{
+			name:           "Not an object",
+			body:           `"stats"`,
+			expectedFields: []string{"body"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.body), &value); err != nil {
+				t.Fatalf("Failed to decode body: %v", err)
+			}
+			assertFields(t, ValidateSchema("Stats", value), tt.expectedFields)
+		})
+	}
+}
+
+func assertFields(t *testing.T, err error, expectedFields []string) {
+	t.Helper()
+	if len(expectedFields) == 0 {
+		if err != nil {
+			t.Errorf("Unexpected validation error: %v", err)
+		}
+		return
+	}
+
+	var validationErrs models.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+	fields := map[string]bool{}
+	for _, e := range validationErrs {
+		fields[e.Field] = true
+	}
+	if len(fields) != len(expectedFields) {
+		t.Errorf("Unexpected validation errors: got %v want fields %v", err, expectedFields)
+	}
+	for _, field := range expectedFields {
+		if !fields[field] {
+			t.Errorf("Expected a validation error for %q, got %v", field, err)
+		}
+	}
+}
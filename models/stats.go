@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type AggregatedStatsResults struct {
 	Stats     []*Stats
 	MedianRTT float64
+	// the median RTT of AI tests run against a warm and a cold model
+	WarmMedianRTT float64
+	ColdMedianRTT float64
 }
 
 func (a *AggregatedStatsResults) HasResults() bool {
@@ -23,6 +27,40 @@ type AggregatedStats struct {
 	SuccessRate    float64 `bson:"success_rate" json:"success_rate"`
 	RoundTripScore float64 `bson:"round_trip_score" json:"round_trip_score"`
 	TotalScore     float64 `bson:"score" json:"score"`
+	// ScoreVersion is the version of the scoring configuration the scores were calculated with
+	ScoreVersion int64          `bson:"score_version" json:"score_version"`
+	Errors       map[string]int `bson:"errors,omitempty" json:"errors,omitempty"`
+	// Tests is the number of tests the stats were aggregated from
+	Tests int `bson:"tests,omitempty" json:"tests,omitempty"`
+	// EffectiveTests is the number of equally weighted tests the tests weighted by their age are as reliable as,
+	// it is only set with a half-life
+	EffectiveTests float64 `bson:"effective_tests,omitempty" json:"effective_tests,omitempty"`
+	// SuccessRateInterval is the confidence interval of the success rate given the number of tests
+	SuccessRateInterval *ConfidenceInterval `bson:"success_rate_interval,omitempty" json:"success_rate_interval,omitempty"`
+	// AI stats of tests run against a warm and a cold model, scored separately
+	Warm *AggregatedStats `bson:"warm,omitempty" json:"warm,omitempty"`
+	Cold *AggregatedStats `bson:"cold,omitempty" json:"cold,omitempty"`
+	// Timing is the breakdown of the round trip time of transcoding tests
+	Timing *TranscodingTiming `bson:"timing,omitempty" json:"timing,omitempty"`
+}
+
+// ConfidenceInterval is the range a rate lies in with 95% confidence
+type ConfidenceInterval struct {
+	Lower float64 `bson:"lower" json:"lower"`
+	Upper float64 `bson:"upper" json:"upper"`
+}
+
+// TranscodingTiming is the average time spent on each step of a transcoding test and the share of segments delivered.
+// It tells whether a slow orchestrator is held up by the network (upload and download) or by transcoding.
+type TranscodingTiming struct {
+	SegDuration          float64 `bson:"seg_duration" json:"seg_duration"`
+	RoundTripTime        float64 `bson:"round_trip_time" json:"round_trip_time"`
+	UploadTime           float64 `bson:"upload_time" json:"upload_time"`
+	DownloadTime         float64 `bson:"download_time" json:"download_time"`
+	TranscodeTime        float64 `bson:"transcode_time" json:"transcode_time"`
+	SegmentsSent         int     `bson:"segments_sent" json:"segments_sent"`
+	SegmentsReceived     int     `bson:"segments_received" json:"segments_received"`
+	SegmentDeliveryRatio float64 `bson:"segment_delivery_ratio" json:"segment_delivery_ratio"`
 }
 
 // Score is a sample of a single score for an orchestrator
@@ -32,6 +70,7 @@ type Score struct {
 	Value        float64 `json:"value" bson:"value"`
 	Model        string  `json:"model" bson:"model"`
 	Pipeline     string  `json:"pipeline" bson:"pipeline"`
+	ScoreVersion int64   `json:"score_version" bson:"score_version"`
 }
 
 // JobType custom types to reference either Transcoding or AI jobs
@@ -82,6 +121,25 @@ type Stats struct {
 	Pipeline        string `json:"pipeline,omitempty" bson:"pipeline,omitempty"`
 	InputParameters string `json:"input_parameters,omitempty" bson:"input_parameters,omitempty"`
 	ResponsePayload string `json:"response_payload,omitempty" bson:"response_payload,omitempty"`
+
+	// Aggregated AI stats split by the state of the model, only set by aggregate queries
+	Warm *ModelStateStats `json:"-" bson:"-"`
+	Cold *ModelStateStats `json:"-" bson:"-"`
+	// Aggregated transcoding timing, only set by aggregate queries
+	Timing *TranscodingTiming `json:"-" bson:"-"`
+	// Number of tests aggregated, only set by aggregate queries
+	Tests int `json:"-" bson:"-"`
+	// Number of equally weighted tests the tests weighted by their age are as reliable as, only set by aggregate
+	// queries with a half-life
+	EffectiveTests float64 `json:"-" bson:"-"`
+}
+
+// ModelStateStats are the aggregated stats of AI tests run against a model in a single warm or cold state
+type ModelStateStats struct {
+	SuccessRate    float64
+	RoundTripTime  float64
+	Tests          int
+	EffectiveTests float64
 }
 
 type Error struct {
@@ -90,6 +148,304 @@ type Error struct {
 	Count     int    `json:"count" bson:"count"`
 }
 
+// ErrorCount is the number of reported errors with the same error code for an orchestrator
+type ErrorCount struct {
+	Orchestrator string `json:"orchestrator"`
+	Region       string `json:"region"`
+	Pipeline     string `json:"pipeline,omitempty"`
+	Model        string `json:"model,omitempty"`
+	ErrorCode    string `json:"error_code"`
+	Count        int    `json:"count"`
+}
+
+// RegionHealth summarises the tests run from a region for a job type
+type RegionHealth struct {
+	Region        string         `json:"region"`
+	JobType       string         `json:"job_type"`
+	Orchestrators int            `json:"orchestrators"`
+	Tests         int            `json:"tests"`
+	SuccessRate   float64        `json:"success_rate"`
+	MedianRTT     float64        `json:"median_rtt"`
+	P90RTT        float64        `json:"p90_rtt"`
+	Errors        map[string]int `json:"errors"`
+	LastReceived  int64          `json:"last_received,omitempty"`
+}
+
+// OrchestratorActivity describes when an orchestrator was tested from a region for a pipeline and model
+type OrchestratorActivity struct {
+	JobType   string
+	Region    string
+	Pipeline  string
+	Model     string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Tests     int
+}
+
+// OrchestratorScore is the current score of an orchestrator in a region for a job type, pipeline and model
+type OrchestratorScore struct {
+	JobType        string  `json:"job_type"`
+	Region         string  `json:"region"`
+	Pipeline       string  `json:"pipeline,omitempty"`
+	Model          string  `json:"model,omitempty"`
+	SuccessRate    float64 `json:"success_rate"`
+	RoundTripScore float64 `json:"round_trip_score"`
+	TotalScore     float64 `json:"score"`
+	ScoreVersion   int64   `json:"score_version"`
+}
+
+// ScoringConfig is a version of the parameters the stats of a job type, or of a single pipeline, are scored with.
+// Only one version of a job type and pipeline is active at a time.
+type ScoringConfig struct {
+	Version  int64  `json:"version"`
+	JobType  string `json:"job_type"`
+	Pipeline string `json:"pipeline,omitempty"`
+	// Scorer is the name of the scoring strategy, the default strategy of the job type is used when empty
+	Scorer               string  `json:"scorer,omitempty"`
+	WeightSuccess        float64 `json:"weight_success"`
+	WeightRTT            float64 `json:"weight_rtt"`
+	DesiredScoreAtMedian float64 `json:"desired_score_at_median"`
+	// HalfLifeMinutes is the age at which a test counts half as much as a new one in the aggregated stats, tests
+	// count equally when it is 0
+	HalfLifeMinutes int `json:"half_life_minutes"`
+	// SuccessRateEstimator is how the success rate is estimated in the total score, 'mean' or 'wilson'
+	SuccessRateEstimator string `json:"success_rate_estimator"`
+	// ModelState is which AI tests the scores are based on, 'blend', 'warm' or 'cold'
+	ModelState string `json:"model_state"`
+	// WarmWeightPct is the weight of warm models in percent in a 'blend' score, the warm and cold tests are
+	// weighted by their number when it is nil
+	WarmWeightPct *int  `json:"warm_weight_pct,omitempty"`
+	Active        bool  `json:"active"`
+	CreatedAt     int64 `json:"created_at"`
+}
+
+// ActiveScoringConfig returns the scoring configuration of the pipeline or, when there is none, of the job type.
+// It returns nil when neither has one.
+func ActiveScoringConfig(configs []*ScoringConfig, jobType, pipeline string) *ScoringConfig {
+	var jobTypeConfig *ScoringConfig
+	for _, config := range configs {
+		if config.JobType != jobType {
+			continue
+		}
+		if pipeline != "" && config.Pipeline == pipeline {
+			return config
+		}
+		if config.Pipeline == "" {
+			jobTypeConfig = config
+		}
+	}
+	return jobTypeConfig
+}
+
+// OrchestratorProfile is everything known about a single orchestrator
+type OrchestratorProfile struct {
+	Address   string               `json:"address"`
+	FirstSeen int64                `json:"first_seen"`
+	LastSeen  int64                `json:"last_seen"`
+	Tests     int                  `json:"tests"`
+	JobTypes  []string             `json:"job_types"`
+	Regions   map[string][]string  `json:"regions"`
+	Pipelines []*Pipeline          `json:"pipelines"`
+	Scores    []*OrchestratorScore `json:"scores"`
+	Errors    []*ErrorCount        `json:"errors"`
+}
+
+// Types of anomalies found by the detector
+const (
+	AnomalySuddenDrop           = "sudden_drop"
+	AnomalySustainedDegradation = "sustained_degradation"
+	AnomalyFlapping             = "flapping"
+)
+
+// IsValidAnomalyType checks if the given anomaly type is known
+func IsValidAnomalyType(anomalyType string) bool {
+	switch anomalyType {
+	case AnomalySuddenDrop, AnomalySustainedDegradation, AnomalyFlapping:
+		return true
+	}
+	return false
+}
+
+// Metrics an anomaly can be found on
+const (
+	AnomalyMetricSuccessRate   = "success_rate"
+	AnomalyMetricRoundTripTime = "round_trip_time"
+)
+
+// Anomaly is an unexpected change in the performance of an orchestrator.  Times are unix timestamps.
+type Anomaly struct {
+	ID           int64   `json:"id"`
+	Type         string  `json:"type"`
+	Metric       string  `json:"metric"`
+	Orchestrator string  `json:"orchestrator"`
+	Region       string  `json:"region"`
+	JobType      string  `json:"job_type"`
+	Pipeline     string  `json:"pipeline,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	Observed     float64 `json:"observed"`
+	Expected     float64 `json:"expected"`
+	Details      string  `json:"details"`
+	WindowStart  int64   `json:"window_start"`
+	WindowEnd    int64   `json:"window_end"`
+	DetectedAt   int64   `json:"detected_at"`
+}
+
+// Metrics a webhook rule can watch
+const (
+	WebhookMetricScore          = "score"
+	WebhookMetricSuccessRate    = "success_rate"
+	WebhookMetricRoundTripScore = "round_trip_score"
+)
+
+// Conditions of a webhook rule
+const (
+	WebhookConditionBelow = "below"
+	WebhookConditionAbove = "above"
+)
+
+// Events sent to a webhook
+const (
+	WebhookEventTriggered = "threshold.triggered"
+	WebhookEventResolved  = "threshold.resolved"
+)
+
+// Statuses of a webhook delivery
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is a rule that notifies a URL when the metric of an orchestrator breaches the threshold for the last
+// DurationMinutes and once it recovers.  The secret is only ever returned when the webhook is registered.
+type Webhook struct {
+	ID              int64   `json:"id"`
+	URL             string  `json:"url"`
+	Secret          string  `json:"secret,omitempty"`
+	Orchestrator    string  `json:"orchestrator,omitempty"`
+	Region          string  `json:"region,omitempty"`
+	JobType         string  `json:"job_type"`
+	Pipeline        string  `json:"pipeline,omitempty"`
+	Model           string  `json:"model,omitempty"`
+	Metric          string  `json:"metric"`
+	Condition       string  `json:"condition"`
+	Threshold       float64 `json:"threshold"`
+	DurationMinutes int     `json:"duration_minutes"`
+	CreatedAt       int64   `json:"created_at"`
+}
+
+// Breached checks if the value meets the condition of the webhook
+func (w *Webhook) Breached(value float64) bool {
+	if w.Condition == WebhookConditionAbove {
+		return value > w.Threshold
+	}
+	return value < w.Threshold
+}
+
+// WebhookState records whether a webhook is triggered for an orchestrator in a region
+type WebhookState struct {
+	WebhookID    int64
+	Orchestrator string
+	Region       string
+	Triggered    bool
+}
+
+// WebhookEvent is the body posted to a webhook.  Times are unix timestamps.
+type WebhookEvent struct {
+	Event        string  `json:"event"`
+	WebhookID    int64   `json:"webhook_id"`
+	Orchestrator string  `json:"orchestrator"`
+	Region       string  `json:"region"`
+	JobType      string  `json:"job_type"`
+	Pipeline     string  `json:"pipeline,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	Metric       string  `json:"metric"`
+	Condition    string  `json:"condition"`
+	Threshold    float64 `json:"threshold"`
+	Value        float64 `json:"value"`
+	// ScoreVersion is the version of the scoring configuration the value was scored with
+	ScoreVersion int64 `json:"score_version"`
+	WindowStart  int64 `json:"window_start"`
+	WindowEnd    int64 `json:"window_end"`
+}
+
+// WebhookDelivery is a single notification sent to a webhook and the result of the attempts to deliver it.  Times are unix timestamps.
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int64           `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	StatusCode    int             `json:"status_code,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	CreatedAt     int64           `json:"created_at"`
+	LastAttemptAt int64           `json:"last_attempt_at,omitempty"`
+	NextAttemptAt int64           `json:"next_attempt_at,omitempty"`
+}
+
+// LatencyPercentiles are the round trip time percentiles, in seconds, of successful tests
+type LatencyPercentiles struct {
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Samples int     `json:"samples"`
+}
+
+// OrchestratorLatency are the round trip time percentiles of an orchestrator in a region
+type OrchestratorLatency struct {
+	Orchestrator string `json:"orchestrator"`
+	Region       string `json:"region"`
+	Pipeline     string `json:"pipeline,omitempty"`
+	Model        string `json:"model,omitempty"`
+	LatencyPercentiles
+}
+
+// LatencyReport are the round trip time percentiles of the whole network and of each orchestrator
+type LatencyReport struct {
+	Network       *LatencyPercentiles    `json:"network"`
+	Orchestrators []*OrchestratorLatency `json:"orchestrators"`
+}
+
+// Statuses of an item submitted as part of a batch
+const (
+	BatchItemAccepted = "accepted"
+	BatchItemRejected = "rejected"
+)
+
+// BatchItemResult describes whether a single item of a batch was accepted
+type BatchItemResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResult describes the outcome of a batch submission
+type BatchResult struct {
+	Accepted int                `json:"accepted"`
+	Rejected int                `json:"rejected"`
+	Results  []*BatchItemResult `json:"results"`
+}
+
+// ValidationError describes a single request parameter or body field that does not match the API specification
+type ValidationError struct {
+	In      string `json:"in"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors are all the problems found while validating a request
+type ValidationErrors []*ValidationError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, e := range v {
+		msgs[i] = fmt.Sprintf("%s %s", e.Field, e.Message)
+	}
+	return strings.Join(msgs, ", ")
+}
+
 type Region struct {
 	Name        string `bson:"id" json:"id"`
 	DisplayName string `bson:"name" json:"name"`
@@ -102,16 +458,199 @@ type Pipeline struct {
 	Regions []string `bson:"regions" json:"regions"`
 }
 
+// LeaderboardEntry is a single ranked row of the leaderboard
+type LeaderboardEntry struct {
+	Rank           int     `json:"rank"`
+	Orchestrator   string  `json:"orchestrator"`
+	Region         string  `json:"region"`
+	Pipeline       string  `json:"pipeline,omitempty"`
+	Model          string  `json:"model,omitempty"`
+	SuccessRate    float64 `json:"success_rate"`
+	RoundTripScore float64 `json:"round_trip_score"`
+	TotalScore     float64 `json:"score"`
+	ScoreVersion   int64   `json:"score_version"`
+	// Tests is the number of tests ranked and SuccessRateInterval the confidence interval of their success rate,
+	// measured over the EffectiveTests when the tests are weighted by their age
+	Tests               int                 `json:"tests"`
+	EffectiveTests      float64             `json:"effective_tests,omitempty"`
+	SuccessRateInterval *ConfidenceInterval `json:"success_rate_interval,omitempty"`
+	Movement            *RankMovement       `json:"movement,omitempty"`
+}
+
+// RankMovement compares a leaderboard entry with the same entry in the preceding window of equal length.
+// A positive RankDelta means the entry moved up the leaderboard.  The score delta is split into the
+// contributions of the success rate and the RTT score and Driver names the larger of the two.
+type RankMovement struct {
+	PreviousRank               int     `json:"previous_rank"`
+	PreviousScore              float64 `json:"previous_score"`
+	RankDelta                  int     `json:"rank_delta"`
+	ScoreDelta                 float64 `json:"score_delta"`
+	SuccessRateDelta           float64 `json:"success_rate_delta"`
+	RoundTripScoreDelta        float64 `json:"round_trip_score_delta"`
+	SuccessRateContribution    float64 `json:"success_rate_contribution"`
+	RoundTripScoreContribution float64 `json:"round_trip_score_contribution"`
+	Driver                     string  `json:"driver"`
+}
+
+// Leaderboard is a page of ranked leaderboard entries
+type Leaderboard struct {
+	Total   int                 `json:"total"`
+	Limit   int                 `json:"limit"`
+	Offset  int                 `json:"offset"`
+	Entries []*LeaderboardEntry `json:"entries"`
+}
+
+// StatsBucket holds the aggregated stats of a single time bucket
+type StatsBucket struct {
+	Start   time.Time
+	Results *AggregatedStatsResults
+}
+
+// ScorePoint is the score of an orchestrator during a single time bucket
+type ScorePoint struct {
+	Timestamp int64 `json:"timestamp"`
+	*AggregatedStats
+}
+
+// ScoreSeries is the score history of an orchestrator in a region for a pipeline/model
+type ScoreSeries struct {
+	Region   string        `json:"region"`
+	Pipeline string        `json:"pipeline,omitempty"`
+	Model    string        `json:"model,omitempty"`
+	Points   []*ScorePoint `json:"points"`
+}
+
+// ScoreHistory is the time-bucketed score history of an orchestrator
+type ScoreHistory struct {
+	Orchestrator string         `json:"orchestrator"`
+	Bucket       string         `json:"bucket"`
+	Series       []*ScoreSeries `json:"series"`
+}
+
+// ScoreExplanation shows how the score of an orchestrator in a region was calculated: the aggregated inputs, the
+// scoring configuration and every intermediate value in the order it was calculated
+type ScoreExplanation struct {
+	Orchestrator string         `json:"orchestrator"`
+	Region       string         `json:"region"`
+	JobType      string         `json:"job_type"`
+	Pipeline     string         `json:"pipeline,omitempty"`
+	Model        string         `json:"model,omitempty"`
+	Scorer       string         `json:"scorer"`
+	Config       *ScoringConfig `json:"config"`
+	Inputs       *ScoreInputs   `json:"inputs"`
+	Steps        []*ScoreStep   `json:"steps"`
+	// The resulting scores, the same as those of the aggregated stats
+	SuccessRate    float64 `json:"success_rate"`
+	RoundTripScore float64 `json:"round_trip_score"`
+	TotalScore     float64 `json:"score"`
+	// AI scores of tests run against a warm and a cold model, explained separately
+	Warm *ScoreExplanation `json:"warm,omitempty"`
+	Cold *ScoreExplanation `json:"cold,omitempty"`
+}
+
+// ScoreInputs are the aggregated stats a score is calculated from
+type ScoreInputs struct {
+	Tests          int     `json:"tests"`
+	EffectiveTests float64 `json:"effective_tests,omitempty"`
+	SuccessRate    float64 `json:"success_rate"`
+	RoundTripTime  float64 `json:"round_trip_time"`
+	// MedianRTT is the median RTT of the successful tests of all orchestrators the stats are ranked with
+	MedianRTT   float64 `json:"median_rtt"`
+	SegDuration float64 `json:"seg_duration,omitempty"`
+}
+
+// ScoreStep is a single intermediate value of a score
+type ScoreStep struct {
+	Name    string  `json:"name"`
+	Formula string  `json:"formula"`
+	Value   float64 `json:"value"`
+}
+
+// Fields the leaderboard can be sorted on
+const (
+	LeaderboardSortScore          = "score"
+	LeaderboardSortSuccessRate    = "success_rate"
+	LeaderboardSortRoundTripScore = "round_trip_score"
+	LeaderboardSortOrchestrator   = "orchestrator"
+	LeaderboardSortRegion         = "region"
+)
+
+// IsValidLeaderboardSortField returns true if the field can be used to sort the leaderboard
+func IsValidLeaderboardSortField(field string) bool {
+	switch field {
+	case LeaderboardSortScore, LeaderboardSortSuccessRate, LeaderboardSortRoundTripScore, LeaderboardSortOrchestrator, LeaderboardSortRegion:
+		return true
+	}
+	return false
+}
+
+// Fields raw stats can be sorted on
+const (
+	RawStatsSortTimestamp     = "timestamp"
+	RawStatsSortRoundTripTime = "round_trip_time"
+)
+
+// IsValidRawStatsSortField returns true if the field can be used to sort raw stats
+func IsValidRawStatsSortField(field string) bool {
+	switch field {
+	case RawStatsSortTimestamp, RawStatsSortRoundTripTime:
+		return true
+	}
+	return false
+}
+
+// RawStatsCursor is the position of the last raw stat of a page, the next page starts right after it.
+// The sort is kept so a cursor can not be used with a different sort than the page it was created for.
+type RawStatsCursor struct {
+	Sort          string    `json:"s"`
+	Order         SortOrder `json:"o"`
+	EventTime     time.Time `json:"t"`
+	RoundTripTime float64   `json:"r,omitempty"`
+	ID            int64     `json:"i"`
+}
+
+// RawStatsPage is a page of raw stats in the order they were sorted in.  Next is the cursor of the following page,
+// it is empty on the last page.
+type RawStatsPage struct {
+	Limit int      `json:"limit"`
+	Next  string   `json:"next,omitempty"`
+	Stats []*Stats `json:"stats"`
+}
+
+// PageQuery holds the pagination parameters of a request
+type PageQuery struct {
+	Limit  int
+	Offset int
+}
+
+// StatsQuery filters stats.  Each of the orchestrators, regions, models and pipelines matches any of its values
+// and matches everything when it is empty.
 type StatsQuery struct {
-	Orchestrator string
-	Region       string
-	Model        string
-	Pipeline     string
-	Since        time.Time
-	Until        time.Time
-	JobType      JobType
-	SortFields   []StatsQuerySortField
-	Limit        int
+	Orchestrators []string
+	Regions       []string
+	Models        []string
+	Pipelines     []string
+	Since         time.Time
+	Until         time.Time
+	JobType       JobType
+	SortFields    []StatsQuerySortField
+	Limit         int
+	// After is the cursor raw stats are returned after
+	After *RawStatsCursor
+	// HalfLifeMinutes weights the tests aggregated by their age, 0 weights them equally.  When it is nil the
+	// half-life of the active scoring configuration is used.
+	HalfLifeMinutes *int
+	// MinSamples leaves out the aggregated stats of fewer tests
+	MinSamples int
+}
+
+// CanScore returns an error when the query spans several AI pipelines or models, whose round trip times
+// can not be scored against each other
+func (q *StatsQuery) CanScore() error {
+	if len(q.Pipelines) > 1 || len(q.Models) > 1 {
+		return ErrMultiplePipelines
+	}
+	return nil
 }
 
 func (s *Stats) JobType() string {
@@ -179,3 +718,21 @@ func (s *Stats) Scan(value interface{}) error {
 // COMMON ERRORS
 var ErrMissingPipeline = errors.New("pipeline required")
 var ErrMissingModel = errors.New("model required")
+var ErrInvalidLimit = errors.New("limit must be a positive integer")
+var ErrInvalidHalfLife = errors.New("half_life_minutes must be a non-negative integer")
+var ErrInvalidMinSamples = errors.New("min_samples must be a non-negative integer")
+var ErrInvalidOffset = errors.New("offset must be a non-negative integer")
+var ErrInvalidSort = errors.New("invalid sort field")
+var ErrInvalidOrder = errors.New("order must be 'asc' or 'desc'")
+var ErrMultiplePipelines = errors.New("only a single pipeline and model can be scored at a time")
+var ErrInvalidCursor = errors.New("cursor is invalid or was created for a different sort")
+var ErrInvalidFormat = errors.New("format must be one of 'json', 'csv' or 'ndjson'")
+var ErrTimestampOutOfRange = errors.New("timestamp is outside of the allowed skew")
+var ErrInvalidBucket = errors.New("bucket must be one of '5m', '1h' or '1d'")
+var ErrTooManyBuckets = errors.New("too many buckets requested, use a larger bucket or a shorter time window")
+var ErrInvalidJobType = errors.New("job_type must be 'ai' or 'transcoding'")
+var ErrRequestValidation = errors.New("request validation failed")
+var ErrOrchestratorNotFound = errors.New("orchestrator not found")
+var ErrScoreNotFound = errors.New("no stats found to score the orchestrator in the region")
+var ErrInvalidAnomalyType = errors.New("type must be one of 'sudden_drop', 'sustained_degradation' or 'flapping'")
+var ErrWebhookNotFound = errors.New("webhook not found")
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/livepeer/leaderboard-serverless/assets"
 	"github.com/livepeer/leaderboard-serverless/common"
@@ -65,125 +67,1256 @@ func (db *DB) Close() {
 func (db *DB) withConnection(fn func(ctx context.Context, conn *pgxpool.Conn) error) error {
 	ctx, cancel := WithTimeout()
 	defer cancel()
+	return db.withContextConnection(ctx, fn)
+}
+
+// withContextConnection acquires a connection that is used with the given context instead of the DB_TIMEOUT
+func (db *DB) withContextConnection(ctx context.Context, fn func(ctx context.Context, conn *pgxpool.Conn) error) error {
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		common.Logger.Debug("Releasing database connection")
+		conn.Release()
+	}()
+
+	return fn(ctx, conn)
+}
+
+const insertStatsQuery = `INSERT INTO events(event_time, orchestrator, region_id, payload, received_time, timestamp_flagged) 
+						SELECT 
+							$5, $1, regions.id, $2, $6, $7
+						FROM 
+								job_types
+						JOIN
+								regions ON regions.name = $3  AND regions.job_type_id = job_types.id
+						WHERE 
+								job_types.name = $4`
+
+// insertStatsArgs returns the arguments of the insertStatsQuery for the given stats
+func insertStatsArgs(stats *models.Stats, received time.Time) ([]interface{}, error) {
+	eventTime, flagged, err := common.ResolveEventTime(stats.Timestamp, received)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{stats.Orchestrator, stats, stats.Region, stats.JobType(), eventTime, received, flagged}, nil
+}
+
+func (db *DB) InsertStats(stats *models.Stats) error {
+	args, err := insertStatsArgs(stats, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		common.Logger.Debug("Inserting stats: %v", stats)
+		_, err := conn.Exec(ctx, insertStatsQuery, args...)
+		if err != nil {
+			common.Logger.Error("Failed to insert stats: %v", err)
+		}
+		return err
+	})
+	return err
+}
+
+// InsertStatsBatch inserts all stats in a single transaction.  Either all stats are inserted or none are.
+func (db *DB) InsertStatsBatch(stats []*models.Stats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	received := time.Now().UTC()
+	batch := &pgx.Batch{}
+	for _, stat := range stats {
+		args, err := insertStatsArgs(stat, received)
+		if err != nil {
+			return err
+		}
+		batch.Queue(insertStatsQuery, args...)
+	}
+
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		// rollback is a no-op once the transaction is committed
+		defer tx.Rollback(ctx)
+
+		common.Logger.Debug("Inserting a batch of %d stats", len(stats))
+		results := tx.SendBatch(ctx, batch)
+		for range stats {
+			if _, err := results.Exec(); err != nil {
+				results.Close()
+				common.Logger.Error("Failed to insert stats batch: %v", err)
+				return err
+			}
+		}
+		if err := results.Close(); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	})
+	return err
+}
+
+// NewEventsChannel is the channel notified by the database whenever a new event is inserted
+const NewEventsChannel = "new_events"
+
+// ListenStats listens for newly inserted stats and calls fn for each of them until the context is
+// cancelled or the connection fails.  A dedicated connection is held for as long as it listens.
+func (db *DB) ListenStats(ctx context.Context, fn func(id int64, stats *models.Stats)) error {
 	conn, err := db.pool.Acquire(ctx)
 	if err != nil {
-		return err
+		return err
+	}
+	defer func() {
+		common.Logger.Debug("Releasing database listener connection")
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+NewEventsChannel); err != nil {
+		return err
+	}
+	common.Logger.Info("Listening for new stats on channel %s", NewEventsChannel)
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			common.Logger.Warn("Ignoring notification with an invalid event id: %s", notification.Payload)
+			continue
+		}
+
+		var stats models.Stats
+		err = conn.QueryRow(ctx, `SELECT payload FROM event_details WHERE id = $1`, id).Scan(&stats)
+		if err != nil {
+			common.Logger.Error("Failed to read new event %d: %v", id, err)
+			continue
+		}
+		fn(id, &stats)
+	}
+}
+
+// BestOrchRegion returns the best region for a given orchestrator and job type in the past 24 hours
+func (db *DB) BestAIRegion(orchestratorId string) (*models.Stats, error) {
+
+	since := common.GetDefaultSince()
+
+	// we will return the best region for AI jobs by adding
+	// a sort order on success_rate and round_trip_time
+	query := &models.StatsQuery{
+		Orchestrators: []string{orchestratorId},
+		Since:         since,
+		Until:         time.Now().UTC(),
+		JobType:       models.AI,
+		SortFields: []models.StatsQuerySortField{
+			models.NewSortField("success_rate", models.SortOrderDesc),
+			models.NewSortField("round_trip_time", models.SortOrderAsc),
+		},
+		Limit: 1,
+	}
+	aggrStatsResults, err := db.AggregatedStats(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(aggrStatsResults.Stats) > 1 {
+		return nil, fmt.Errorf("too many stats objects returned.  Found %d stats when searching for the best AI region for orchestrator. Expected 1", len(aggrStatsResults.Stats))
+	}
+	if len(aggrStatsResults.Stats) == 0 {
+		common.Logger.Debug("No best AI region stats found for orchestrator %v", orchestratorId)
+		return nil, nil
+	}
+	return aggrStatsResults.Stats[0], nil
+}
+
+// BestAIRegions returns the best region of a given orchestrator for every AI pipeline and model
+// it was tested on in the past 24 hours, ordered by pipeline and model
+func (db *DB) BestAIRegions(orchestratorId string) ([]*models.Stats, error) {
+	// rows are sorted so the best region of each pipeline and model comes first
+	query := &models.StatsQuery{
+		Orchestrators: []string{orchestratorId},
+		Since:         common.GetDefaultSince(),
+		Until:         time.Now().UTC(),
+		JobType:       models.AI,
+		SortFields: []models.StatsQuerySortField{
+			models.NewSortField("pipeline", models.SortOrderAsc),
+			models.NewSortField("model", models.SortOrderAsc),
+			models.NewSortField("success_rate", models.SortOrderDesc),
+			models.NewSortField("round_trip_time", models.SortOrderAsc),
+		},
+	}
+	aggrStatsResults, err := db.AggregatedStats(query)
+	if err != nil {
+		return nil, err
+	}
+
+	best := []*models.Stats{}
+	for _, stats := range aggrStatsResults.Stats {
+		if n := len(best); n > 0 && best[n-1].Pipeline == stats.Pipeline && best[n-1].Model == stats.Model {
+			continue
+		}
+		best = append(best, stats)
+	}
+	common.Logger.Debug("Found best AI regions for %d pipelines and models of orchestrator %v", len(best), orchestratorId)
+	return best, nil
+}
+
+func (db *DB) MedianRTT(statsQuery *models.StatsQuery) (float64, error) {
+
+	//make a copy of the query and then clear out any query fields
+	//that might interfere with the median RTT query
+	statsQueryCopy := *statsQuery
+	statsQueryCopy.Limit = 0
+	statsQueryCopy.SortFields = nil
+
+	medianRTT := -1.0
+	err := setJobTypeIfEmpty(&statsQueryCopy)
+	if err != nil {
+		return medianRTT, err
+	}
+
+	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		baseSQLQuery := `SELECT PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY COALESCE(round_trip_time, 0)) AS median_round_trip_time FROM event_details WHERE round_trip_time != 0 AND success_rate = 1 AND event_time >= $1 AND event_time <= $2`
+		finalQuery, args := db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, nil)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		rows, err := conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				medianRTTCol sql.NullFloat64
+			)
+			if err := rows.Scan(&medianRTTCol); err != nil {
+				return err
+			}
+			medianRTT = db.extractFloat64(medianRTTCol)
+			common.Logger.Debug("Determined media rtt of: %d ", medianRTT)
+		}
+		return nil
+	})
+	return medianRTT, err
+}
+
+func (db *DB) AggregatedStats(statsQuery *models.StatsQuery) (*models.AggregatedStatsResults, error) {
+	aggregatedStatsResults := models.AggregatedStatsResults{
+		Stats: []*models.Stats{},
+	}
+
+	err := setJobTypeIfEmpty(statsQuery)
+	if err != nil {
+		return &aggregatedStatsResults, err
+	}
+
+	aggregatedStatsResults.Stats, err = db.aggregatedStats(statsQuery)
+	if err != nil {
+		return nil, err
+	}
+	//calculate median RTT for the aggregated stats
+	aggregatedStatsResults.MedianRTT, err = db.MedianRTT(statsQuery)
+	if err != nil {
+		return &aggregatedStatsResults, err
+	}
+	if statsQuery.JobType == models.AI {
+		aggregatedStatsResults.WarmMedianRTT, aggregatedStatsResults.ColdMedianRTT, err = db.modelStateMedianRTT(statsQuery)
+	}
+	common.Logger.Debug("Returning %d aggregated stats", len(aggregatedStatsResults.Stats))
+	return &aggregatedStatsResults, err
+}
+
+// AggregatedStatsByModel returns the aggregated stats of the job type of the query split by pipeline and model, each
+// with the median RTTs of its pipeline and model taken across all orchestrators in the regions of the query, so they
+// can be scored the same way as AggregatedStats.  The stats are aggregated with a single query and the median RTTs
+// with another one grouped by pipeline and model.
+func (db *DB) AggregatedStatsByModel(statsQuery *models.StatsQuery) ([]*models.AggregatedStatsResults, error) {
+	err := setJobTypeIfEmpty(statsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := db.aggregatedStats(statsQuery)
+	if err != nil {
+		return nil, err
+	}
+	medianQuery := *statsQuery
+	medianQuery.Orchestrators = nil
+	medians, err := db.modelMedianRTTs(&medianQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*models.AggregatedStatsResults{}
+	resultsByModel := map[[2]string]*models.AggregatedStatsResults{}
+	for _, stat := range stats {
+		key := [2]string{stat.Pipeline, stat.Model}
+		result, ok := resultsByModel[key]
+		if !ok {
+			result = &models.AggregatedStatsResults{Stats: []*models.Stats{}}
+			if median, ok := medians[key]; ok {
+				result.MedianRTT = median.MedianRTT
+				if statsQuery.JobType == models.AI {
+					result.WarmMedianRTT, result.ColdMedianRTT = median.WarmMedianRTT, median.ColdMedianRTT
+				}
+			}
+			resultsByModel[key] = result
+			results = append(results, result)
+		}
+		result.Stats = append(result.Stats, stat)
+	}
+	common.Logger.Debug("Returning %d aggregated stats of %d pipelines and models", len(stats), len(results))
+	return results, nil
+}
+
+// aggregatedStats aggregates the stats of every orchestrator, region, pipeline and model of the query
+func (db *DB) aggregatedStats(statsQuery *models.StatsQuery) ([]*models.Stats, error) {
+	stats := []*models.Stats{}
+	weight := recencyWeight(db.halfLifeMinutes(statsQuery))
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+
+		baseSQLQuery := `SELECT orchestrator, payload->>'model' as model, payload->>'pipeline' as pipeline, region_name as region, job_type_name as job_type, ` +
+			avgColumn("COALESCE(success_rate, 0)", "", weight) + ` as success_rate, ` + avgColumn("COALESCE(seg_duration, 0)", "", weight) + ` as seg_duration, ` +
+			avgColumn("COALESCE(round_trip_time, 0)", "", weight) + ` as round_trip_time, ` + modelStateColumns(weight) + `, ` + transcodingTimingColumns(weight) + `, COUNT(*) as tests, ` +
+			effectiveTestsColumn("", weight) + ` as effective_tests FROM event_details WHERE event_time >= $1 AND event_time <= $2`
+		groupFields := []string{"orchestrator", "region", "job_type", "payload->>'model'", "payload->>'pipeline'"}
+
+		// the stats of fewer tests are left out before they are sorted and limited
+		groupedQuery := *statsQuery
+		groupedQuery.SortFields = nil
+		groupedQuery.Limit = 0
+		finalQuery, args := db.buildAggregateQueryArgs(&groupedQuery, baseSQLQuery, groupFields)
+		if statsQuery.MinSamples > 0 {
+			args = append(args, statsQuery.MinSamples)
+			finalQuery += fmt.Sprintf(` HAVING COUNT(*) >= $%d`, len(args))
+		}
+		finalQuery += orderAndLimit(statsQuery)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		rows, err := conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				orchestrator   sql.NullString
+				model          sql.NullString
+				pipeline       sql.NullString
+				region         sql.NullString
+				job_type       sql.NullString
+				successRate    sql.NullFloat64
+				segDuration    sql.NullFloat64
+				roundTripTime  sql.NullFloat64
+				warm           modelStateColumnValues
+				cold           modelStateColumnValues
+				timing         transcodingTimingColumnValues
+				tests          int
+				effectiveTests sql.NullFloat64
+			)
+			if err := rows.Scan(&orchestrator, &model, &pipeline, &region, &job_type, &successRate, &segDuration, &roundTripTime,
+				&warm.successRate, &warm.roundTripTime, &warm.tests, &warm.effectiveTests, &cold.successRate, &cold.roundTripTime, &cold.tests, &cold.effectiveTests,
+				&timing.uploadTime, &timing.downloadTime, &timing.transcodeTime, &timing.segmentsSent, &timing.segmentsReceived, &tests, &effectiveTests); err != nil {
+				return err
+			}
+			common.Logger.Trace("Found stats for orchestrator %v, region %v, job_type %v, ", orchestrator, region, job_type)
+			stat := &models.Stats{
+				Orchestrator:  db.extractString(orchestrator),
+				Region:        db.extractString(region),
+				SuccessRate:   db.extractFloat64(successRate),
+				SegDuration:   db.extractFloat64(segDuration),
+				RoundTripTime: db.extractFloat64(roundTripTime),
+				//model and pipeline are added here to ensure
+				//the JobType() function can understand the type of job
+				Model:          db.extractString(model),
+				Pipeline:       db.extractString(pipeline),
+				Tests:          tests,
+				EffectiveTests: db.extractFloat64(effectiveTests),
+			}
+			if stat.JobType() == models.AI.String() {
+				stat.Warm = warm.toModelStateStats(db)
+				stat.Cold = cold.toModelStateStats(db)
+			} else {
+				stat.Timing = timing.toTranscodingTiming(db, stat)
+			}
+			stats = append(stats, stat)
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// modelStateFilter matches AI tests run against a warm model, the state is only stored when the model was warm
+const modelStateFilter = `COALESCE((payload->>'model_is_warm')::boolean, false)`
+
+// modelStateColumns aggregates the success rate, RTT and number of tests separately for warm and cold models
+func modelStateColumns(weight string) string {
+	return avgColumn("COALESCE(success_rate, 0)", modelStateFilter, weight) + ` as warm_success_rate, ` + avgColumn("COALESCE(round_trip_time, 0)", modelStateFilter, weight) + ` as warm_round_trip_time, COUNT(*) FILTER (WHERE ` + modelStateFilter + `) as warm_tests, ` +
+		effectiveTestsColumn(modelStateFilter, weight) + ` as warm_effective_tests, ` +
+		avgColumn("COALESCE(success_rate, 0)", "NOT "+modelStateFilter, weight) + ` as cold_success_rate, ` + avgColumn("COALESCE(round_trip_time, 0)", "NOT "+modelStateFilter, weight) + ` as cold_round_trip_time, COUNT(*) FILTER (WHERE NOT ` + modelStateFilter + `) as cold_tests, ` +
+		effectiveTestsColumn("NOT "+modelStateFilter, weight) + ` as cold_effective_tests`
+}
+
+// modelStateColumnValues are the values of the modelStateColumns for a single model state
+type modelStateColumnValues struct {
+	successRate    sql.NullFloat64
+	roundTripTime  sql.NullFloat64
+	tests          int
+	effectiveTests sql.NullFloat64
+}
+
+// toModelStateStats returns nil when no tests were run in the model state
+func (v modelStateColumnValues) toModelStateStats(db *DB) *models.ModelStateStats {
+	if v.tests == 0 {
+		return nil
+	}
+	return &models.ModelStateStats{
+		SuccessRate:    db.extractFloat64(v.successRate),
+		RoundTripTime:  db.extractFloat64(v.roundTripTime),
+		Tests:          v.tests,
+		EffectiveTests: db.extractFloat64(v.effectiveTests),
+	}
+}
+
+// transcodingTimingColumns aggregates the timing breakdown of transcoding tests and the number of segments sent and received
+func transcodingTimingColumns(weight string) string {
+	return avgColumn("COALESCE((payload->>'upload_time')::float, 0)", "", weight) + ` as upload_time, ` + avgColumn("COALESCE((payload->>'download_time')::float, 0)", "", weight) + ` as download_time, ` +
+		avgColumn("COALESCE((payload->>'transcode_time')::float, 0)", "", weight) + ` as transcode_time, ` +
+		`SUM(COALESCE((payload->>'segments_sent')::int, 0)) as segments_sent, SUM(COALESCE((payload->>'segments_received')::int, 0)) as segments_received`
+}
+
+// avgColumn averages the expression over the tests matching the filter.  When a weight is given every test counts
+// as much as its weight.
+func avgColumn(expr, filter, weight string) string {
+	filterClause := ""
+	if filter != "" {
+		filterClause = ` FILTER (WHERE ` + filter + `)`
+	}
+	if weight == "" {
+		return `AVG(` + expr + `)` + filterClause
+	}
+	return `(SUM(` + weight + ` * ` + expr + `)` + filterClause + ` / SUM(` + weight + `)` + filterClause + `)`
+}
+
+// effectiveTestsColumn is the number of equally weighted tests the tests matching the filter are as reliable as when
+// they are weighted, (Σw)²/Σw².  It is 0 without a weight, every test counts fully then.
+func effectiveTestsColumn(filter, weight string) string {
+	if weight == "" {
+		return `0::float`
+	}
+	filterClause := ""
+	if filter != "" {
+		filterClause = ` FILTER (WHERE ` + filter + `)`
+	}
+	return `(POWER(SUM(` + weight + `)` + filterClause + `, 2) / SUM(` + weight + ` * ` + weight + `)` + filterClause + `)`
+}
+
+// maxHalfLives caps the age of a test in half-lives so neither its weight nor the square of it underflow
+const maxHalfLives = 500
+
+// recencyWeight halves the weight of a test every half-life before the end of the window ($2), it returns no weight
+// when the half-life is 0 and every test counts equally
+func recencyWeight(halfLifeMinutes int) string {
+	if halfLifeMinutes <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(`POWER(0.5, LEAST(EXTRACT(EPOCH FROM ($2::timestamptz - event_time)) / %d, %d))`, halfLifeMinutes*60, maxHalfLives)
+}
+
+// halfLifeMinutes returns the half-life of the query or, when it has none, of the active scoring configuration of
+// its job type and pipeline.  Tests count equally when the scoring configurations can not be loaded.
+func (db *DB) halfLifeMinutes(query *models.StatsQuery) int {
+	if query.HalfLifeMinutes != nil {
+		return *query.HalfLifeMinutes
+	}
+	configs, err := db.ScoringConfigs()
+	if err != nil {
+		common.Logger.Error("Failed to load the scoring configs, the tests are weighted equally: %v", err)
+		return 0
+	}
+	pipeline := ""
+	if len(query.Pipelines) == 1 {
+		pipeline = query.Pipelines[0]
+	}
+	if config := models.ActiveScoringConfig(configs, query.JobType.String(), pipeline); config != nil {
+		return config.HalfLifeMinutes
+	}
+	return 0
+}
+
+// transcodingTimingColumnValues are the values of the transcodingTimingColumns
+type transcodingTimingColumnValues struct {
+	uploadTime       sql.NullFloat64
+	downloadTime     sql.NullFloat64
+	transcodeTime    sql.NullFloat64
+	segmentsSent     sql.NullInt64
+	segmentsReceived sql.NullInt64
+}
+
+// toTranscodingTiming combines the timing breakdown with the averages of the stats, the delivery ratio is 0 when no segments were sent
+func (v transcodingTimingColumnValues) toTranscodingTiming(db *DB, stats *models.Stats) *models.TranscodingTiming {
+	timing := &models.TranscodingTiming{
+		SegDuration:      stats.SegDuration,
+		RoundTripTime:    stats.RoundTripTime,
+		UploadTime:       db.extractFloat64(v.uploadTime),
+		DownloadTime:     db.extractFloat64(v.downloadTime),
+		TranscodeTime:    db.extractFloat64(v.transcodeTime),
+		SegmentsSent:     int(v.segmentsSent.Int64),
+		SegmentsReceived: int(v.segmentsReceived.Int64),
+	}
+	if timing.SegmentsSent > 0 {
+		timing.SegmentDeliveryRatio = float64(timing.SegmentsReceived) / float64(timing.SegmentsSent)
+	}
+	return timing
+}
+
+// modelStateMedianRTT calculates the median RTT of successful AI tests run against a warm and a cold model
+func (db *DB) modelStateMedianRTT(statsQuery *models.StatsQuery) (float64, float64, error) {
+	statsQueryCopy := *statsQuery
+	statsQueryCopy.Limit = 0
+	statsQueryCopy.SortFields = nil
+
+	var warmMedianRTT, coldMedianRTT float64
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		baseSQLQuery := `SELECT PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) FILTER (WHERE ` + modelStateFilter + `) AS warm_median_round_trip_time, PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) FILTER (WHERE NOT ` + modelStateFilter + `) AS cold_median_round_trip_time FROM event_details WHERE round_trip_time != 0 AND success_rate = 1 AND event_time >= $1 AND event_time <= $2`
+		finalQuery, args := db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, nil)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		var warmCol, coldCol sql.NullFloat64
+		if err := conn.QueryRow(ctx, finalQuery, args...).Scan(&warmCol, &coldCol); err != nil {
+			return err
+		}
+		warmMedianRTT = db.extractFloat64(warmCol)
+		coldMedianRTT = db.extractFloat64(coldCol)
+		return nil
+	})
+	return warmMedianRTT, coldMedianRTT, err
+}
+
+// modelMedianRTTs returns the median RTTs of all tests and of warm and cold models for every pipeline and model of
+// the query in a single query
+func (db *DB) modelMedianRTTs(statsQuery *models.StatsQuery) (map[[2]string]*models.AggregatedStatsResults, error) {
+	statsQueryCopy := *statsQuery
+	statsQueryCopy.Limit = 0
+	statsQueryCopy.SortFields = nil
+
+	medians := map[[2]string]*models.AggregatedStatsResults{}
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		baseSQLQuery := `SELECT payload->>'pipeline' as pipeline, payload->>'model' as model, PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) AS median_round_trip_time, ` +
+			`PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) FILTER (WHERE ` + modelStateFilter + `) AS warm_median_round_trip_time, PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) FILTER (WHERE NOT ` + modelStateFilter + `) AS cold_median_round_trip_time ` +
+			`FROM event_details WHERE round_trip_time != 0 AND success_rate = 1 AND event_time >= $1 AND event_time <= $2`
+		groupFields := []string{"payload->>'pipeline'", "payload->>'model'"}
+		finalQuery, args := db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, groupFields)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		rows, err := conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				pipeline, model          sql.NullString
+				median, warmCol, coldCol sql.NullFloat64
+			)
+			if err := rows.Scan(&pipeline, &model, &median, &warmCol, &coldCol); err != nil {
+				return err
+			}
+			medians[[2]string{db.extractString(pipeline), db.extractString(model)}] = &models.AggregatedStatsResults{
+				MedianRTT:     db.extractFloat64(median),
+				WarmMedianRTT: db.extractFloat64(warmCol),
+				ColdMedianRTT: db.extractFloat64(coldCol),
+			}
+		}
+		return rows.Err()
+	})
+	return medians, err
+}
+
+// StatsHistory returns the aggregated stats grouped into time buckets of the given size.
+// Each bucket carries the network median RTTs of that bucket so it can be scored on its own.
+func (db *DB) StatsHistory(statsQuery *models.StatsQuery, bucketSize time.Duration) ([]*models.StatsBucket, error) {
+	buckets := []*models.StatsBucket{}
+
+	err := setJobTypeIfEmpty(statsQuery)
+	if err != nil {
+		return buckets, err
+	}
+
+	bucketSeconds := int64(bucketSize.Seconds())
+	if bucketSeconds <= 0 {
+		return buckets, errors.New("bucket size must be at least one second")
+	}
+	bucketColumn := fmt.Sprintf("to_timestamp(floor(extract(epoch FROM event_time) / %d) * %d) AS bucket", bucketSeconds, bucketSeconds)
+	bucketSort := []models.StatsQuerySortField{models.NewSortField("bucket", models.SortOrderAsc)}
+
+	// the median RTT is calculated across all orchestrators in each bucket
+	medianQuery := *statsQuery
+	medianQuery.Orchestrators = nil
+	medianQuery.Limit = 0
+	medianQuery.SortFields = bucketSort
+
+	statsQueryCopy := *statsQuery
+	statsQueryCopy.Limit = 0
+	statsQueryCopy.SortFields = bucketSort
+
+	bucketsByStart := make(map[time.Time]*models.StatsBucket)
+	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+
+		baseSQLQuery := `SELECT ` + bucketColumn + `, PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY COALESCE(round_trip_time, 0)) AS median_round_trip_time, ` +
+			`PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) FILTER (WHERE ` + modelStateFilter + `) AS warm_median_round_trip_time, PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) FILTER (WHERE NOT ` + modelStateFilter + `) AS cold_median_round_trip_time ` +
+			`FROM event_details WHERE round_trip_time != 0 AND success_rate = 1 AND event_time >= $1 AND event_time <= $2`
+		finalQuery, args := db.buildAggregateQueryArgs(&medianQuery, baseSQLQuery, []string{"bucket"})
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		rows, err := conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		// the median RTT of all tests and of the warm and cold AI tests of each bucket
+		medians := make(map[time.Time][3]float64)
+		for rows.Next() {
+			var (
+				bucket       time.Time
+				medianRTTCol sql.NullFloat64
+				warmCol      sql.NullFloat64
+				coldCol      sql.NullFloat64
+			)
+			if err := rows.Scan(&bucket, &medianRTTCol, &warmCol, &coldCol); err != nil {
+				rows.Close()
+				return err
+			}
+			medians[bucket.UTC()] = [3]float64{db.extractFloat64(medianRTTCol), db.extractFloat64(warmCol), db.extractFloat64(coldCol)}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		baseSQLQuery = `SELECT ` + bucketColumn + `, orchestrator, payload->>'model' as model, payload->>'pipeline' as pipeline, region_name as region, AVG(COALESCE(success_rate, 0))  as success_rate, AVG(COALESCE(seg_duration, 0)) as seg_duration, AVG(COALESCE(round_trip_time, 0)) as round_trip_time, ` +
+			modelStateColumns("") + `, COUNT(*) as tests FROM event_details WHERE event_time >= $1 AND event_time <= $2`
+		groupFields := []string{"bucket", "orchestrator", "region", "job_type_name", "payload->>'model'", "payload->>'pipeline'"}
+		finalQuery, args = db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, groupFields)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		rows, err = conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				bucket        time.Time
+				orchestrator  sql.NullString
+				model         sql.NullString
+				pipeline      sql.NullString
+				region        sql.NullString
+				successRate   sql.NullFloat64
+				segDuration   sql.NullFloat64
+				roundTripTime sql.NullFloat64
+				warm          modelStateColumnValues
+				cold          modelStateColumnValues
+				tests         int
+			)
+			if err := rows.Scan(&bucket, &orchestrator, &model, &pipeline, &region, &successRate, &segDuration, &roundTripTime,
+				&warm.successRate, &warm.roundTripTime, &warm.tests, &warm.effectiveTests, &cold.successRate, &cold.roundTripTime, &cold.tests, &cold.effectiveTests, &tests); err != nil {
+				return err
+			}
+			bucket = bucket.UTC()
+			statsBucket, ok := bucketsByStart[bucket]
+			if !ok {
+				statsBucket = &models.StatsBucket{
+					Start: bucket,
+					Results: &models.AggregatedStatsResults{
+						Stats:         []*models.Stats{},
+						MedianRTT:     medians[bucket][0],
+						WarmMedianRTT: medians[bucket][1],
+						ColdMedianRTT: medians[bucket][2],
+					},
+				}
+				bucketsByStart[bucket] = statsBucket
+				buckets = append(buckets, statsBucket)
+			}
+			stats := &models.Stats{
+				Orchestrator:  db.extractString(orchestrator),
+				Region:        db.extractString(region),
+				SuccessRate:   db.extractFloat64(successRate),
+				SegDuration:   db.extractFloat64(segDuration),
+				RoundTripTime: db.extractFloat64(roundTripTime),
+				Model:         db.extractString(model),
+				Pipeline:      db.extractString(pipeline),
+				Tests:         tests,
+			}
+			// AI stats are split by model state so they are scored the same way as the aggregated stats
+			if stats.JobType() == models.AI.String() {
+				stats.Warm = warm.toModelStateStats(db)
+				stats.Cold = cold.toModelStateStats(db)
+			}
+			statsBucket.Results.Stats = append(statsBucket.Results.Stats, stats)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	common.Logger.Debug("Returning %d stats buckets", len(buckets))
+	return buckets, nil
+}
+
+// ErrorCounts returns the number of reported errors per error code for each orchestrator, region and pipeline/model
+func (db *DB) ErrorCounts(statsQuery *models.StatsQuery) ([]*models.ErrorCount, error) {
+	errorCounts := []*models.ErrorCount{}
+
+	err := setJobTypeIfEmpty(statsQuery)
+	if err != nil {
+		return errorCounts, err
+	}
+
+	statsQueryCopy := *statsQuery
+	statsQueryCopy.Limit = 0
+	statsQueryCopy.SortFields = []models.StatsQuerySortField{
+		models.NewSortField("count", models.SortOrderDesc),
+		models.NewSortField("orchestrator", models.SortOrderAsc),
+		models.NewSortField("region", models.SortOrderAsc),
+		models.NewSortField("pipeline", models.SortOrderAsc),
+		models.NewSortField("model", models.SortOrderAsc),
+		models.NewSortField("error_code", models.SortOrderAsc),
+	}
+
+	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+
+		// payload->'errors' is null when a tester reported no errors, so only arrays are unnested
+		baseSQLQuery := `SELECT orchestrator, payload->>'model' as model, payload->>'pipeline' as pipeline, region_name as region, COALESCE(err->>'error_code', '') as error_code, SUM(COALESCE((err->>'count')::int, 1)) as count FROM event_details, jsonb_array_elements(CASE WHEN jsonb_typeof(payload->'errors') = 'array' THEN payload->'errors' ELSE '[]'::jsonb END) AS err WHERE event_time >= $1 AND event_time <= $2`
+		groupFields := []string{"orchestrator", "region", "job_type_name", "payload->>'model'", "payload->>'pipeline'", "COALESCE(err->>'error_code', '')"}
+		finalQuery, args := db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, groupFields)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		rows, err := conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				orchestrator sql.NullString
+				model        sql.NullString
+				pipeline     sql.NullString
+				region       sql.NullString
+				errorCode    sql.NullString
+				count        int64
+			)
+			if err := rows.Scan(&orchestrator, &model, &pipeline, &region, &errorCode, &count); err != nil {
+				return err
+			}
+			errorCounts = append(errorCounts, &models.ErrorCount{
+				Orchestrator: db.extractString(orchestrator),
+				Region:       db.extractString(region),
+				Pipeline:     db.extractString(pipeline),
+				Model:        db.extractString(model),
+				ErrorCode:    db.extractString(errorCode),
+				Count:        int(count),
+			})
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	common.Logger.Debug("Returning %d error counts", len(errorCounts))
+	return errorCounts, nil
+}
+
+// RegionHealth summarises the tests run from each region for each job type.  The job type is only
+// filtered on when it is part of the query so that all job types are summarised by default.
+func (db *DB) RegionHealth(statsQuery *models.StatsQuery) ([]*models.RegionHealth, error) {
+	health := []*models.RegionHealth{}
+
+	statsQueryCopy := *statsQuery
+	statsQueryCopy.Limit = 0
+	statsQueryCopy.SortFields = []models.StatsQuerySortField{
+		models.NewSortField("region", models.SortOrderAsc),
+		models.NewSortField("job_type", models.SortOrderAsc),
+	}
+
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		// RTT percentiles only include successful tests, the same as the median RTT used for scoring
+		baseSQLQuery := `SELECT region_name as region, job_type_name as job_type, COUNT(DISTINCT orchestrator) as orchestrators, COUNT(*) as tests, AVG(COALESCE(success_rate, 0)) as success_rate, PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) FILTER (WHERE round_trip_time != 0 AND success_rate = 1) as median_rtt, PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY round_trip_time) FILTER (WHERE round_trip_time != 0 AND success_rate = 1) as p90_rtt, MAX(received_time) as last_received FROM event_details WHERE event_time >= $1 AND event_time <= $2`
+		groupFields := []string{"region_name", "job_type_name"}
+		finalQuery, args := db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, groupFields)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		rows, err := conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		byRegion := make(map[string]*models.RegionHealth)
+		for rows.Next() {
+			var (
+				region        sql.NullString
+				jobType       sql.NullString
+				orchestrators int64
+				tests         int64
+				successRate   sql.NullFloat64
+				medianRTT     sql.NullFloat64
+				p90RTT        sql.NullFloat64
+				lastReceived  time.Time
+			)
+			if err := rows.Scan(&region, &jobType, &orchestrators, &tests, &successRate, &medianRTT, &p90RTT, &lastReceived); err != nil {
+				return err
+			}
+			regionHealth := &models.RegionHealth{
+				Region:        db.extractString(region),
+				JobType:       db.extractString(jobType),
+				Orchestrators: int(orchestrators),
+				Tests:         int(tests),
+				SuccessRate:   db.extractFloat64(successRate),
+				MedianRTT:     db.extractFloat64(medianRTT),
+				P90RTT:        db.extractFloat64(p90RTT),
+				Errors:        make(map[string]int),
+				LastReceived:  lastReceived.Unix(),
+			}
+			health = append(health, regionHealth)
+			byRegion[regionHealth.Region+"/"+regionHealth.JobType] = regionHealth
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// payload->'errors' is null when a tester reported no errors, so only arrays are unnested
+		errorsStatsQuery := statsQueryCopy
+		errorsStatsQuery.SortFields = nil
+		baseSQLQuery = `SELECT region_name as region, job_type_name as job_type, COALESCE(err->>'error_code', '') as error_code, SUM(COALESCE((err->>'count')::int, 1)) as count FROM event_details, jsonb_array_elements(CASE WHEN jsonb_typeof(payload->'errors') = 'array' THEN payload->'errors' ELSE '[]'::jsonb END) AS err WHERE event_time >= $1 AND event_time <= $2`
+		groupFields = []string{"region_name", "job_type_name", "COALESCE(err->>'error_code', '')"}
+		finalQuery, args = db.buildAggregateQueryArgs(&errorsStatsQuery, baseSQLQuery, groupFields)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		errorRows, err := conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer errorRows.Close()
+		for errorRows.Next() {
+			var (
+				region    sql.NullString
+				jobType   sql.NullString
+				errorCode sql.NullString
+				count     int64
+			)
+			if err := errorRows.Scan(&region, &jobType, &errorCode, &count); err != nil {
+				return err
+			}
+			if regionHealth, ok := byRegion[db.extractString(region)+"/"+db.extractString(jobType)]; ok {
+				regionHealth.Errors[db.extractString(errorCode)] += int(count)
+			}
+		}
+		return errorRows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	common.Logger.Debug("Returning the health of %d regions", len(health))
+	return health, nil
+}
+
+// OrchestratorActivity returns when an orchestrator was first and last tested from each region for each
+// job type, pipeline and model since the given time.  The tests are found through the orchestrator and event time index.
+func (db *DB) OrchestratorActivity(orchestratorId string, since time.Time) ([]*models.OrchestratorActivity, error) {
+	activity := []*models.OrchestratorActivity{}
+
+	query := &models.StatsQuery{
+		Orchestrators: []string{orchestratorId},
+		Since:         since.UTC(),
+		Until:         time.Now().UTC(),
+		SortFields: []models.StatsQuerySortField{
+			models.NewSortField("job_type", models.SortOrderAsc),
+			models.NewSortField("region", models.SortOrderAsc),
+			models.NewSortField("pipeline", models.SortOrderAsc),
+			models.NewSortField("model", models.SortOrderAsc),
+		},
+	}
+
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		baseSQLQuery := `SELECT job_type_name as job_type, region_name as region, payload->>'pipeline' as pipeline, payload->>'model' as model, MIN(event_time) as first_seen, MAX(event_time) as last_seen, COUNT(*) as tests FROM event_details WHERE event_time >= $1 AND event_time <= $2`
+		groupFields := []string{"job_type_name", "region_name", "payload->>'pipeline'", "payload->>'model'"}
+		finalQuery, args := db.buildAggregateQueryArgs(query, baseSQLQuery, groupFields)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		rows, err := conn.Query(ctx, finalQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				jobType   sql.NullString
+				region    sql.NullString
+				pipeline  sql.NullString
+				model     sql.NullString
+				firstSeen time.Time
+				lastSeen  time.Time
+				tests     int64
+			)
+			if err := rows.Scan(&jobType, &region, &pipeline, &model, &firstSeen, &lastSeen, &tests); err != nil {
+				return err
+			}
+			activity = append(activity, &models.OrchestratorActivity{
+				JobType:   db.extractString(jobType),
+				Region:    db.extractString(region),
+				Pipeline:  db.extractString(pipeline),
+				Model:     db.extractString(model),
+				FirstSeen: firstSeen.UTC(),
+				LastSeen:  lastSeen.UTC(),
+				Tests:     int(tests),
+			})
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	common.Logger.Debug("Returning %d activity rows for orchestrator %v", len(activity), orchestratorId)
+	return activity, nil
+}
+
+// InsertAnomalies records the anomalies and returns how many of them were new.
+// Anomalies already recorded for the same window are ignored.  Flapping spans the whole baseline, so a flapping
+// anomaly whose window overlaps one already recorded for the same orchestrator is ignored as well.
+func (db *DB) InsertAnomalies(anomalies []*models.Anomaly) (int, error) {
+	if len(anomalies) == 0 {
+		return 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, a := range anomalies {
+		query := `INSERT INTO anomalies (window_start, window_end, type, metric, orchestrator, region, job_type, pipeline, model, observed, expected, details) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING`
+		if a.Type == models.AnomalyFlapping {
+			query = `INSERT INTO anomalies (window_start, window_end, type, metric, orchestrator, region, job_type, pipeline, model, observed, expected, details)
+				SELECT $1::timestamptz, $2::timestamptz, $3::varchar, $4::varchar, $5::varchar, $6::varchar, $7::varchar, $8::varchar, $9::varchar, $10::double precision, $11::double precision, $12::text
+				WHERE NOT EXISTS (
+					SELECT 1 FROM anomalies
+					WHERE orchestrator = $5 AND region = $6 AND job_type = $7 AND pipeline = $8 AND model = $9 AND type = $3 AND metric = $4
+					AND window_end > $1::timestamptz AND window_start < $2::timestamptz
+				)
+				ON CONFLICT DO NOTHING`
+		}
+		batch.Queue(query, time.Unix(a.WindowStart, 0).UTC(), time.Unix(a.WindowEnd, 0).UTC(), a.Type, a.Metric, a.Orchestrator, a.Region, a.JobType, a.Pipeline, a.Model, a.Observed, a.Expected, a.Details)
+	}
+
+	inserted := 0
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		common.Logger.Debug("Inserting %d anomalies", len(anomalies))
+		results := conn.SendBatch(ctx, batch)
+		defer results.Close()
+		for range anomalies {
+			tag, err := results.Exec()
+			if err != nil {
+				common.Logger.Error("Failed to insert anomaly: %v", err)
+				return err
+			}
+			inserted += int(tag.RowsAffected())
+		}
+		return nil
+	})
+	return inserted, err
+}
+
+// Anomalies returns the anomalies whose window ended within the time range of the query, most recent first.
+// An empty anomaly type returns anomalies of all types.
+func (db *DB) Anomalies(query *models.StatsQuery, anomalyType string) ([]*models.Anomaly, error) {
+	anomalies := []*models.Anomaly{}
+
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		sqlQuery := `SELECT id, detected_at, window_start, window_end, type, metric, orchestrator, region, job_type, pipeline, model, observed, expected, details FROM anomalies WHERE window_end >= $1 AND window_end <= $2`
+		args := []interface{}{query.Since, query.Until}
+
+		filters := []struct {
+			column string
+			values []string
+		}{
+			{"orchestrator", query.Orchestrators},
+			{"region", query.Regions},
+			{"pipeline", query.Pipelines},
+			{"model", query.Models},
+		}
+		if anomalyType != "" {
+			filters = append(filters, struct {
+				column string
+				values []string
+			}{"type", []string{anomalyType}})
+		}
+		if query.JobType != models.Unknown {
+			filters = append(filters, struct {
+				column string
+				values []string
+			}{"job_type", []string{query.JobType.String()}})
+		}
+		for _, filter := range filters {
+			if len(filter.values) == 0 {
+				continue
+			}
+			args = append(args, filter.values)
+			sqlQuery += fmt.Sprintf(` AND %s = ANY($%d)`, filter.column, len(args))
+		}
+		sqlQuery += ` ORDER BY window_end DESC, id DESC`
+		if query.Limit > 0 {
+			sqlQuery += fmt.Sprintf(" LIMIT %d", query.Limit)
+		}
+
+		common.Logger.Debug("Running query: %v with args: %v", sqlQuery, args)
+		rows, err := conn.Query(ctx, sqlQuery, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				a           models.Anomaly
+				detectedAt  time.Time
+				windowStart time.Time
+				windowEnd   time.Time
+			)
+			if err := rows.Scan(&a.ID, &detectedAt, &windowStart, &windowEnd, &a.Type, &a.Metric, &a.Orchestrator, &a.Region, &a.JobType, &a.Pipeline, &a.Model, &a.Observed, &a.Expected, &a.Details); err != nil {
+				return err
+			}
+			a.DetectedAt = detectedAt.Unix()
+			a.WindowStart = windowStart.Unix()
+			a.WindowEnd = windowEnd.Unix()
+			anomalies = append(anomalies, &a)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
 	}
-	defer func() {
-		common.Logger.Debug("Releasing database connection")
-		conn.Release()
-	}()
+	common.Logger.Debug("Returning %d anomalies", len(anomalies))
+	return anomalies, nil
+}
 
-	return fn(ctx, conn)
+// InsertWebhook registers the webhook and sets its id and creation time
+func (db *DB) InsertWebhook(webhook *models.Webhook) error {
+	return db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		var createdAt time.Time
+		err := conn.QueryRow(ctx, `INSERT INTO webhooks (url, secret, orchestrator, region, job_type, pipeline, model, metric, condition, threshold, duration_minutes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at`,
+			webhook.URL, webhook.Secret, webhook.Orchestrator, webhook.Region, webhook.JobType, webhook.Pipeline, webhook.Model, webhook.Metric, webhook.Condition, webhook.Threshold, webhook.DurationMinutes).Scan(&webhook.ID, &createdAt)
+		if err != nil {
+			common.Logger.Error("Failed to insert webhook: %v", err)
+			return err
+		}
+		webhook.CreatedAt = createdAt.Unix()
+		return nil
+	})
 }
 
-func (db *DB) InsertStats(stats *models.Stats) error {
+// Webhooks returns every registered webhook, including its secret, ordered by id
+func (db *DB) Webhooks() ([]*models.Webhook, error) {
+	webhooks := []*models.Webhook{}
+
 	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
-		qry := `INSERT INTO events(event_time, orchestrator, region_id, payload) 
-						SELECT 
-							CURRENT_TIMESTAMP, $1, regions.id, $2
-						FROM 
-								job_types
-						JOIN
-								regions ON regions.name = $3  AND regions.job_type_id = job_types.id
-						WHERE 
-								job_types.name = $4`
-		common.Logger.Debug("Inserting stats: %v", stats)
-		_, err := conn.Exec(ctx, qry, stats.Orchestrator, stats, stats.Region, stats.JobType())
+		rows, err := conn.Query(ctx, `SELECT id, created_at, url, secret, orchestrator, region, job_type, pipeline, model, metric, condition, threshold, duration_minutes FROM webhooks ORDER BY id`)
 		if err != nil {
-			common.Logger.Error("Failed to insert stats: %v", err)
+			return err
 		}
-		return err
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				webhook   models.Webhook
+				createdAt time.Time
+			)
+			if err := rows.Scan(&webhook.ID, &createdAt, &webhook.URL, &webhook.Secret, &webhook.Orchestrator, &webhook.Region, &webhook.JobType, &webhook.Pipeline, &webhook.Model, &webhook.Metric, &webhook.Condition, &webhook.Threshold, &webhook.DurationMinutes); err != nil {
+				return err
+			}
+			webhook.CreatedAt = createdAt.Unix()
+			webhooks = append(webhooks, &webhook)
+		}
+		return rows.Err()
 	})
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
 }
 
-// BestOrchRegion returns the best region for a given orchestrator and job type in the past 24 hours
-func (db *DB) BestAIRegion(orchestratorId string) (*models.Stats, error) {
+// DeleteWebhook removes the webhook together with its state and delivery history.  It reports whether the webhook existed.
+func (db *DB) DeleteWebhook(id int64) (bool, error) {
+	deleted := false
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		tag, err := conn.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		deleted = tag.RowsAffected() > 0
+		return nil
+	})
+	return deleted, err
+}
 
-	since := common.GetDefaultSince()
+// WebhookStates returns whether the webhook is triggered for each orchestrator and region it was evaluated for
+func (db *DB) WebhookStates(webhookID int64) ([]*models.WebhookState, error) {
+	states := []*models.WebhookState{}
 
-	// we will return the best region for AI jobs by adding
-	// a sort order on success_rate and round_trip_time
-	query := &models.StatsQuery{
-		Orchestrator: orchestratorId,
-		Since:        since,
-		Until:        time.Now().UTC(),
-		JobType:      models.AI,
-		SortFields: []models.StatsQuerySortField{
-			models.NewSortField("success_rate", models.SortOrderDesc),
-			models.NewSortField("round_trip_time", models.SortOrderAsc),
-		},
-		Limit: 1,
-	}
-	aggrStatsResults, err := db.AggregatedStats(query)
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		rows, err := conn.Query(ctx, `SELECT webhook_id, orchestrator, region, triggered FROM webhook_states WHERE webhook_id = $1`, webhookID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var state models.WebhookState
+			if err := rows.Scan(&state.WebhookID, &state.Orchestrator, &state.Region, &state.Triggered); err != nil {
+				return err
+			}
+			states = append(states, &state)
+		}
+		return rows.Err()
+	})
 	if err != nil {
 		return nil, err
 	}
-	if len(aggrStatsResults.Stats) > 1 {
-		return nil, fmt.Errorf("too many stats objects returned.  Found %d stats when searching for the best AI region for orchestrator. Expected 1", len(aggrStatsResults.Stats))
-	}
-	if len(aggrStatsResults.Stats) == 0 {
-		common.Logger.Debug("No best AI region stats found for orchestrator %v", orchestratorId)
-		return nil, nil
+	return states, nil
+}
+
+// InsertWebhookDeliveries records the deliveries of the events of a webhook together with its new states in a
+// single transaction, so a state change is never recorded without its notification.  It sets the id and creation
+// time of every delivery.  A pending delivery should be due right away, so it is retried if the first attempt is
+// never recorded.
+func (db *DB) InsertWebhookDeliveries(deliveries []*models.WebhookDelivery, states []*models.WebhookState) error {
+	if len(deliveries) == 0 && len(states) == 0 {
+		return nil
 	}
-	return aggrStatsResults.Stats[0], nil
+
+	return db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		// rollback is a no-op once the transaction is committed
+		defer tx.Rollback(ctx)
+
+		for _, delivery := range deliveries {
+			var createdAt time.Time
+			err := tx.QueryRow(ctx, `INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+				delivery.WebhookID, delivery.Event, string(delivery.Payload), delivery.Status, unixTimeOrNil(delivery.NextAttemptAt)).Scan(&delivery.ID, &createdAt)
+			if err != nil {
+				common.Logger.Error("Failed to insert webhook delivery: %v", err)
+				return err
+			}
+			delivery.CreatedAt = createdAt.Unix()
+		}
+		for _, state := range states {
+			_, err := tx.Exec(ctx, `INSERT INTO webhook_states (webhook_id, orchestrator, region, triggered) VALUES ($1, $2, $3, $4) ON CONFLICT (webhook_id, orchestrator, region) DO UPDATE SET triggered = EXCLUDED.triggered, updated_at = CURRENT_TIMESTAMP`,
+				state.WebhookID, state.Orchestrator, state.Region, state.Triggered)
+			if err != nil {
+				common.Logger.Error("Failed to update webhook state: %v", err)
+				return err
+			}
+		}
+		return tx.Commit(ctx)
+	})
 }
 
-func (db *DB) MedianRTT(statsQuery *models.StatsQuery) (float64, error) {
+// UpdateWebhookDelivery records the result of the latest attempt to deliver a notification
+func (db *DB) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		_, err := conn.Exec(ctx, `UPDATE webhook_deliveries SET status = $2, attempts = $3, status_code = $4, error = $5, last_attempt_at = $6, next_attempt_at = $7 WHERE id = $1`,
+			delivery.ID, delivery.Status, delivery.Attempts, delivery.StatusCode, delivery.Error, unixTimeOrNil(delivery.LastAttemptAt), unixTimeOrNil(delivery.NextAttemptAt))
+		if err != nil {
+			common.Logger.Error("Failed to update webhook delivery: %v", err)
+		}
+		return err
+	})
+}
 
-	//make a copy of the query and then clear out any query fields
-	//that might interfere with the median RTT query
-	statsQueryCopy := *statsQuery
-	statsQueryCopy.Limit = 0
-	statsQueryCopy.SortFields = nil
+// DueWebhookDeliveries returns the pending deliveries whose next attempt is due, oldest first
+func (db *DB) DueWebhookDeliveries(now time.Time) ([]*models.WebhookDelivery, error) {
+	return db.webhookDeliveries(`WHERE status = $1 AND next_attempt_at <= $2 ORDER BY id`, models.WebhookDeliveryPending, now)
+}
 
-	medianRTT := -1.0
-	err := setJobTypeIfEmpty(&statsQueryCopy)
-	if err != nil {
-		return medianRTT, err
-	}
+// WebhookDeliveries returns the delivery history of a webhook, most recent first
+func (db *DB) WebhookDeliveries(webhookID int64, limit int) ([]*models.WebhookDelivery, error) {
+	return db.webhookDeliveries(`WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2`, webhookID, limit)
+}
 
-	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
-		baseSQLQuery := `SELECT PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY COALESCE(round_trip_time, 0)) AS median_round_trip_time FROM event_details WHERE round_trip_time != 0 AND success_rate = 1 AND event_time >= $1 AND event_time <= $2`
-		finalQuery, args := db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, nil)
+func (db *DB) webhookDeliveries(where string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	deliveries := []*models.WebhookDelivery{}
 
-		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
-		rows, err := conn.Query(ctx, finalQuery, args...)
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		sqlQuery := `SELECT id, webhook_id, created_at, event, payload, status, attempts, status_code, error, last_attempt_at, next_attempt_at FROM webhook_deliveries ` + where
+		common.Logger.Debug("Running query: %v with args: %v", sqlQuery, args)
+		rows, err := conn.Query(ctx, sqlQuery, args...)
 		if err != nil {
 			return err
 		}
 		defer rows.Close()
 		for rows.Next() {
 			var (
-				medianRTTCol sql.NullFloat64
+				delivery      models.WebhookDelivery
+				createdAt     time.Time
+				payload       []byte
+				lastAttemptAt sql.NullTime
+				nextAttemptAt sql.NullTime
 			)
-			if err := rows.Scan(&medianRTTCol); err != nil {
+			if err := rows.Scan(&delivery.ID, &delivery.WebhookID, &createdAt, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts, &delivery.StatusCode, &delivery.Error, &lastAttemptAt, &nextAttemptAt); err != nil {
 				return err
 			}
-			medianRTT = db.extractFloat64(medianRTTCol)
-			common.Logger.Debug("Determined media rtt of: %d ", medianRTT)
+			delivery.CreatedAt = createdAt.Unix()
+			delivery.Payload = payload
+			if lastAttemptAt.Valid {
+				delivery.LastAttemptAt = lastAttemptAt.Time.Unix()
+			}
+			if nextAttemptAt.Valid {
+				delivery.NextAttemptAt = nextAttemptAt.Time.Unix()
+			}
+			deliveries = append(deliveries, &delivery)
 		}
-		return nil
+		return rows.Err()
 	})
-	return medianRTT, err
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
 }
 
-func (db *DB) AggregatedStats(statsQuery *models.StatsQuery) (*models.AggregatedStatsResults, error) {
-	aggregatedStatsResults := models.AggregatedStatsResults{
-		Stats: []*models.Stats{},
+// unixTimeOrNil converts a unix timestamp to a time, zero is stored as NULL
+func unixTimeOrNil(timestamp int64) interface{} {
+	if timestamp == 0 {
+		return nil
 	}
+	return time.Unix(timestamp, 0).UTC()
+}
+
+// latencyPercentilesColumns selects the RTT percentiles and the number of samples they were calculated from
+const latencyPercentilesColumns = `PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY round_trip_time) as p50, PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY round_trip_time) as p90, PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY round_trip_time) as p95, PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY round_trip_time) as p99, COUNT(*) as samples`
+
+// latencyPercentilesFilter limits the percentiles to successful tests, the same as the median RTT
+const latencyPercentilesFilter = `round_trip_time != 0 AND success_rate = 1 AND event_time >= $1 AND event_time <= $2`
+
+// LatencyPercentiles returns the RTT percentiles of successful tests for each orchestrator, region and pipeline/model
+func (db *DB) LatencyPercentiles(statsQuery *models.StatsQuery) ([]*models.OrchestratorLatency, error) {
+	latencies := []*models.OrchestratorLatency{}
 
 	err := setJobTypeIfEmpty(statsQuery)
 	if err != nil {
-		return &aggregatedStatsResults, err
+		return latencies, err
 	}
 
-	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+	statsQueryCopy := *statsQuery
+	statsQueryCopy.Limit = 0
+	statsQueryCopy.SortFields = []models.StatsQuerySortField{
+		models.NewSortField("orchestrator", models.SortOrderAsc),
+		models.NewSortField("region", models.SortOrderAsc),
+		models.NewSortField("pipeline", models.SortOrderAsc),
+		models.NewSortField("model", models.SortOrderAsc),
+	}
 
-		baseSQLQuery := `SELECT orchestrator, payload->>'model' as model, payload->>'pipeline' as pipeline, region_name as region, job_type_name as job_type, AVG(COALESCE(success_rate, 0))  as success_rate, AVG(COALESCE(seg_duration, 0)) as seg_duration, AVG(COALESCE(round_trip_time, 0)) as round_trip_time FROM event_details WHERE event_time >= $1 AND event_time <= $2`
-		groupFields := []string{"orchestrator", "region", "job_type", "payload->>'model'", "payload->>'pipeline'"}
-		finalQuery, args := db.buildAggregateQueryArgs(statsQuery, baseSQLQuery, groupFields)
+	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		baseSQLQuery := `SELECT orchestrator, payload->>'model' as model, payload->>'pipeline' as pipeline, region_name as region, ` + latencyPercentilesColumns + ` FROM event_details WHERE ` + latencyPercentilesFilter
+		groupFields := []string{"orchestrator", "region", "payload->>'model'", "payload->>'pipeline'"}
+		finalQuery, args := db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, groupFields)
 
 		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
 		rows, err := conn.Query(ctx, finalQuery, args...)
@@ -193,40 +1326,81 @@ func (db *DB) AggregatedStats(statsQuery *models.StatsQuery) (*models.Aggregated
 		defer rows.Close()
 		for rows.Next() {
 			var (
-				orchestrator  sql.NullString
-				model         sql.NullString
-				pipeline      sql.NullString
-				region        sql.NullString
-				job_type      sql.NullString
-				successRate   sql.NullFloat64
-				segDuration   sql.NullFloat64
-				roundTripTime sql.NullFloat64
+				orchestrator sql.NullString
+				model        sql.NullString
+				pipeline     sql.NullString
+				region       sql.NullString
+				p50          sql.NullFloat64
+				p90          sql.NullFloat64
+				p95          sql.NullFloat64
+				p99          sql.NullFloat64
+				samples      int64
 			)
-			if err := rows.Scan(&orchestrator, &model, &pipeline, &region, &job_type, &successRate, &segDuration, &roundTripTime); err != nil {
+			if err := rows.Scan(&orchestrator, &model, &pipeline, &region, &p50, &p90, &p95, &p99, &samples); err != nil {
 				return err
 			}
-			common.Logger.Trace("Found stats for orchestrator %v, region %v, job_type %v, ", orchestrator, region, job_type)
-			aggregatedStatsResults.Stats = append(aggregatedStatsResults.Stats, &models.Stats{
-				Orchestrator:  db.extractString(orchestrator),
-				Region:        db.extractString(region),
-				SuccessRate:   db.extractFloat64(successRate),
-				SegDuration:   db.extractFloat64(segDuration),
-				RoundTripTime: db.extractFloat64(roundTripTime),
-				//model and pipeline are added here to ensure
-				//the JobType() function can understand the type of job
-				Model:    db.extractString(model),
-				Pipeline: db.extractString(pipeline),
+			latencies = append(latencies, &models.OrchestratorLatency{
+				Orchestrator: db.extractString(orchestrator),
+				Region:       db.extractString(region),
+				Pipeline:     db.extractString(pipeline),
+				Model:        db.extractString(model),
+				LatencyPercentiles: models.LatencyPercentiles{
+					P50:     db.extractFloat64(p50),
+					P90:     db.extractFloat64(p90),
+					P95:     db.extractFloat64(p95),
+					P99:     db.extractFloat64(p99),
+					Samples: int(samples),
+				},
 			})
 		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	common.Logger.Debug("Returning %d latency percentiles", len(latencies))
+	return latencies, nil
+}
+
+// NetworkLatencyPercentiles returns the RTT percentiles of successful tests across all matching orchestrators
+func (db *DB) NetworkLatencyPercentiles(statsQuery *models.StatsQuery) (*models.LatencyPercentiles, error) {
+	network := &models.LatencyPercentiles{}
+
+	statsQueryCopy := *statsQuery
+	statsQueryCopy.Limit = 0
+	statsQueryCopy.SortFields = nil
+
+	err := setJobTypeIfEmpty(&statsQueryCopy)
+	if err != nil {
+		return network, err
+	}
+
+	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		baseSQLQuery := `SELECT ` + latencyPercentilesColumns + ` FROM event_details WHERE ` + latencyPercentilesFilter
+		finalQuery, args := db.buildAggregateQueryArgs(&statsQueryCopy, baseSQLQuery, nil)
+
+		common.Logger.Debug("Running query: %v with args: %v", finalQuery, args)
+		var (
+			p50     sql.NullFloat64
+			p90     sql.NullFloat64
+			p95     sql.NullFloat64
+			p99     sql.NullFloat64
+			samples int64
+		)
+		if err := conn.QueryRow(ctx, finalQuery, args...).Scan(&p50, &p90, &p95, &p99, &samples); err != nil {
+			return err
+		}
+		network.P50 = db.extractFloat64(p50)
+		network.P90 = db.extractFloat64(p90)
+		network.P95 = db.extractFloat64(p95)
+		network.P99 = db.extractFloat64(p99)
+		network.Samples = int(samples)
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	//calculate median RTT for the aggregated stats
-	aggregatedStatsResults.MedianRTT, err = db.MedianRTT(statsQuery)
-	common.Logger.Debug("Returning %d aggregated stats", len(aggregatedStatsResults.Stats))
-	return &aggregatedStatsResults, err
+	return network, nil
 }
 
 // helper function to build the query arguments for the aggregated stats and related mediaRTT queries
@@ -234,24 +1408,24 @@ func (db *DB) buildAggregateQueryArgs(query *models.StatsQuery, baseQuery string
 	args := []interface{}{query.Since, query.Until}
 
 	paramNumber := 3
-	if query.Orchestrator != "" {
-		baseQuery += fmt.Sprintf(` AND orchestrator = $%d`, paramNumber)
-		args = append(args, query.Orchestrator)
+	if len(query.Orchestrators) > 0 {
+		baseQuery += fmt.Sprintf(` AND orchestrator = ANY($%d)`, paramNumber)
+		args = append(args, query.Orchestrators)
 		paramNumber++
 	}
-	if query.Region != "" {
-		baseQuery += fmt.Sprintf(` AND region_name = $%d`, paramNumber)
-		args = append(args, query.Region)
+	if len(query.Regions) > 0 {
+		baseQuery += fmt.Sprintf(` AND region_name = ANY($%d)`, paramNumber)
+		args = append(args, query.Regions)
 		paramNumber++
 	}
-	if query.Pipeline != "" {
-		baseQuery += fmt.Sprintf(` AND payload->>'pipeline' = $%d`, paramNumber)
-		args = append(args, query.Pipeline)
+	if len(query.Pipelines) > 0 {
+		baseQuery += fmt.Sprintf(` AND payload->>'pipeline' = ANY($%d)`, paramNumber)
+		args = append(args, query.Pipelines)
 		paramNumber++
 	}
-	if query.Model != "" {
-		baseQuery += fmt.Sprintf(` AND payload->>'model' = $%d`, paramNumber)
-		args = append(args, query.Model)
+	if len(query.Models) > 0 {
+		baseQuery += fmt.Sprintf(` AND payload->>'model' = ANY($%d)`, paramNumber)
+		args = append(args, query.Models)
 		paramNumber++
 	}
 	if query.JobType != models.Unknown {
@@ -267,53 +1441,109 @@ func (db *DB) buildAggregateQueryArgs(query *models.StatsQuery, baseQuery string
 		}
 	}
 
+	return baseQuery + orderAndLimit(query), args
+}
+
+// orderAndLimit returns the ORDER BY and LIMIT clauses of the query
+func orderAndLimit(query *models.StatsQuery) string {
+	clauses := ""
 	if query.SortFields != nil && len(query.SortFields) > 0 {
-		baseQuery += " ORDER BY "
+		clauses += " ORDER BY "
 		for i, field := range query.SortFields {
 			if i > 0 {
-				baseQuery += ", "
+				clauses += ", "
 			}
-			baseQuery += field.String()
+			clauses += field.String()
 		}
 	}
 	if query.Limit > 0 {
-		baseQuery += fmt.Sprintf(" LIMIT %d", query.Limit)
+		clauses += fmt.Sprintf(" LIMIT %d", query.Limit)
 	}
-	return baseQuery, args
+	return clauses
 }
 
 func (db *DB) RawStats(query *models.StatsQuery) ([]*models.Stats, error) {
-
+	ctx, cancel := WithTimeout()
+	defer cancel()
 	stats := []*models.Stats{}
-	err := setJobTypeIfEmpty(query)
+	err := db.EachRawStats(ctx, query, func(stat *models.Stats, cursor *models.RawStatsCursor) error {
+		stats = append(stats, stat)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return stats, nil
+}
 
-	err = db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
-		baseQuery := `SELECT payload FROM event_details WHERE orchestrator = $1 AND event_time >= $2 AND event_time <= $3`
-		args := []interface{}{query.Orchestrator, query.Since, query.Until}
+// rawStatsSortColumns are the columns raw stats are sorted on, ties are broken by the id of the event
+var rawStatsSortColumns = map[string]string{
+	models.RawStatsSortTimestamp:     "event_time",
+	models.RawStatsSortRoundTripTime: "COALESCE(round_trip_time, 0)",
+}
+
+// EachRawStats calls fn for every raw stat matching the query as the rows are read from the database,
+// so that callers can stream the results without holding all of them in memory.  Each stat is passed
+// with the cursor of its position, which the next page of a paginated query starts after.  The rows are read
+// until the context is done rather than within the DB_TIMEOUT, so that large exports are not cut off.
+func (db *DB) EachRawStats(ctx context.Context, query *models.StatsQuery, fn func(stat *models.Stats, cursor *models.RawStatsCursor) error) error {
+	err := setJobTypeIfEmpty(query)
+	if err != nil {
+		return err
+	}
+
+	sortField := models.NewSortField(models.RawStatsSortTimestamp, models.SortOrderDesc)
+	if len(query.SortFields) > 0 {
+		sortField = query.SortFields[0]
+	}
+	sortColumn, ok := rawStatsSortColumns[sortField.Field]
+	if !ok {
+		return models.ErrInvalidSort
+	}
+
+	return db.withContextConnection(ctx, func(ctx context.Context, conn *pgxpool.Conn) error {
+		baseQuery := `SELECT id, event_time, COALESCE(round_trip_time, 0), payload FROM event_details WHERE orchestrator = ANY($1) AND event_time >= $2 AND event_time <= $3`
+		args := []interface{}{query.Orchestrators, query.Since, query.Until}
 
 		paramNumber := 4
-		if query.Region != "" {
-			baseQuery += fmt.Sprintf(` AND region_name = $%d`, paramNumber)
-			args = append(args, query.Region)
+		if len(query.Regions) > 0 {
+			baseQuery += fmt.Sprintf(` AND region_name = ANY($%d)`, paramNumber)
+			args = append(args, query.Regions)
 			paramNumber++
 		}
-		if query.Pipeline != "" {
-			baseQuery += fmt.Sprintf(` AND payload->>'pipeline' = $%d`, paramNumber)
-			args = append(args, query.Pipeline)
+		if len(query.Pipelines) > 0 {
+			baseQuery += fmt.Sprintf(` AND payload->>'pipeline' = ANY($%d)`, paramNumber)
+			args = append(args, query.Pipelines)
 			paramNumber++
 		}
-		if query.Model != "" {
-			baseQuery += fmt.Sprintf(` AND payload->>'model' = $%d`, paramNumber)
-			args = append(args, query.Model)
+		if len(query.Models) > 0 {
+			baseQuery += fmt.Sprintf(` AND payload->>'model' = ANY($%d)`, paramNumber)
+			args = append(args, query.Models)
 			paramNumber++
 		}
 		if query.JobType != models.Unknown {
 			baseQuery += fmt.Sprintf(" AND job_type_name = '%s'", query.JobType.String())
 		}
-		baseQuery += " ORDER BY event_time DESC"
+
+		// keyset pagination, the page starts after the position of the cursor in the sort order
+		if query.After != nil {
+			comparison := "<"
+			if sortField.Order == models.SortOrderAsc {
+				comparison = ">"
+			}
+			var value interface{} = query.After.EventTime
+			if sortField.Field == models.RawStatsSortRoundTripTime {
+				value = query.After.RoundTripTime
+			}
+			baseQuery += fmt.Sprintf(` AND (%s, id) %s ($%d, $%d)`, sortColumn, comparison, paramNumber, paramNumber+1)
+			args = append(args, value, query.After.ID)
+			paramNumber += 2
+		}
+		baseQuery += fmt.Sprintf(" ORDER BY %s %s, id %s", sortColumn, sortField.Order.String(), sortField.Order.String())
+		if query.Limit > 0 {
+			baseQuery += fmt.Sprintf(" LIMIT %d", query.Limit)
+		}
+
 		common.Logger.Debug("Running query: %v with args: %v", baseQuery, args)
 		rows, err := conn.Query(ctx, baseQuery, args...)
 		if err != nil {
@@ -322,14 +1552,16 @@ func (db *DB) RawStats(query *models.StatsQuery) ([]*models.Stats, error) {
 		defer rows.Close()
 		for rows.Next() {
 			var stat models.Stats
-			if err := rows.Scan(&stat); err != nil {
+			cursor := &models.RawStatsCursor{Sort: sortField.Field, Order: sortField.Order}
+			if err := rows.Scan(&cursor.ID, &cursor.EventTime, &cursor.RoundTripTime, &stat); err != nil {
+				return err
+			}
+			if err := fn(&stat, cursor); err != nil {
 				return err
 			}
-			stats = append(stats, &stat)
 		}
-		return nil
+		return rows.Err()
 	})
-	return stats, err
 }
 
 // Regions returns the regions from the database or the cache if available
@@ -448,14 +1680,14 @@ func (db *DB) Pipelines(query *models.StatsQuery) ([]*models.Pipeline, error) {
 		params := []interface{}{query.Since, query.Until}
 
 		// Add region filter if provided
-		if query.Region != "" {
-			qry += ` AND r.name = $3`
-			params = append(params, query.Region)
+		if len(query.Regions) > 0 {
+			qry += ` AND r.name = ANY($3)`
+			params = append(params, query.Regions)
 		}
 
 		qry += ` GROUP BY pipeline ORDER BY pipeline`
 
-		common.Logger.Debug("Running query: %v with args: %v, %v, %v", qry, query.Since, query.Until, query.Region)
+		common.Logger.Debug("Running query: %v with args: %v, %v, %v", qry, query.Since, query.Until, query.Regions)
 		rows, err := conn.Query(ctx, qry, params...)
 
 		if err != nil {
@@ -483,6 +1715,46 @@ func (db *DB) Pipelines(query *models.StatsQuery) ([]*models.Pipeline, error) {
 	return pipelines, err
 }
 
+// ScoringConfigs returns the active scoring configuration of every job type and pipeline from the database or the cache if available
+func (db *DB) ScoringConfigs() ([]*models.ScoringConfig, error) {
+
+	//check the cache for non-expired scoring configs
+	cacheResults := db.internalCache.GetScoringConfigs()
+	if cacheResults.CacheHit && !cacheResults.CacheExpired {
+		return cacheResults.Results.([]*models.ScoringConfig), nil
+	}
+
+	configs := []*models.ScoringConfig{}
+	err := db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
+		rows, err := conn.Query(ctx, `SELECT version, created_at, job_type, pipeline, scorer, weight_success, weight_rtt, desired_score_at_median, half_life_minutes, success_rate_estimator, model_state, warm_weight_pct, active
+			FROM scoring_configs WHERE active ORDER BY job_type, pipeline`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				config    models.ScoringConfig
+				createdAt time.Time
+			)
+			if err := rows.Scan(&config.Version, &createdAt, &config.JobType, &config.Pipeline, &config.Scorer, &config.WeightSuccess, &config.WeightRTT, &config.DesiredScoreAtMedian, &config.HalfLifeMinutes, &config.SuccessRateEstimator, &config.ModelState, &config.WarmWeightPct, &config.Active); err != nil {
+				return err
+			}
+			config.CreatedAt = createdAt.Unix()
+			configs = append(configs, &config)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		//since we got an error, we will invalidate the cache to ensure we don't keep returning stale data
+		common.Logger.Error("Failed to retrieve scoring configs from the database.  Cache will be invalidated.  Error: %v", err)
+		db.internalCache.InvalidateScoringConfigsCache()
+		return nil, err
+	}
+	db.internalCache.UpdateScoringConfigs(configs)
+	return configs, nil
+}
+
 func (db *DB) ensureDatabase() error {
 	common.Logger.Info("Ensuring the database exists")
 	return db.withConnection(func(ctx context.Context, conn *pgxpool.Conn) error {
@@ -569,12 +1841,12 @@ func setJobTypeIfEmpty(query *models.StatsQuery) error {
 	}
 
 	// validate and adjust the job type
-	if query.Model != "" || query.Pipeline != "" {
+	if len(query.Models) > 0 || len(query.Pipelines) > 0 {
 		if query.JobType == models.Transcoding {
 			return errors.New("job type is set to a Transcoding, but model or pipeline is set")
 		}
 		query.JobType = models.AI
-	} else if query.Model == "" && query.Pipeline == "" {
+	} else if len(query.Models) == 0 && len(query.Pipelines) == 0 {
 		if query.JobType == models.AI {
 			return errors.New("job type is set to AI, but model or pipeline is not set")
 		}
@@ -3,6 +3,7 @@ package postgres_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	_ "github.com/lib/pq"
@@ -58,6 +59,120 @@ func TestPostgresRegionsRefDataSetup(t *testing.T) {
 	}
 }
 
+func TestPostgresScoringConfigsSetup(t *testing.T) {
+	testutils.NewDB(t)
+
+	configs, err := db.Store.ScoringConfigs()
+	if err != nil {
+		t.Fatalf("Expected no error when retrieving scoring configs, got %v", err)
+	}
+
+	// the migrations activate the parameters stats were scored with before they were configurable
+	expected := []*models.ScoringConfig{
+		{Version: 1, JobType: models.AI.String(), WeightSuccess: 0.65, WeightRTT: 0.35, DesiredScoreAtMedian: 0.8, SuccessRateEstimator: "mean", ModelState: "blend", Active: true},
+		{Version: 2, JobType: models.Transcoding.String(), WeightSuccess: 0.65, WeightRTT: 0.35, DesiredScoreAtMedian: 0.8, SuccessRateEstimator: "mean", ModelState: "blend", Active: true},
+	}
+	for _, config := range configs {
+		if config.CreatedAt == 0 {
+			t.Errorf("Expected the creation time of the scoring config: %+v", config)
+		}
+		config.CreatedAt = 0
+	}
+	if diff := cmp.Diff(expected, configs); diff != "" {
+		t.Errorf("Unexpected scoring configs (-want +got):\n%s", diff)
+	}
+}
+
+func TestPostgresAggregatedStatsRecencyWeighted(t *testing.T) {
+	testutils.NewDB(t)
+	now := time.Now().UTC()
+
+	// an orchestrator that failed hours ago and recovered since
+	failedLongAgo := testutils.GetTranscodingStats()
+	failedLongAgo.SuccessRate = 0
+	failedLongAgo.Timestamp = now.Add(-10 * time.Hour).Unix()
+	recovered := testutils.GetTranscodingStats()
+	recovered.SuccessRate = 1
+	recovered.Timestamp = now.Add(-time.Minute).Unix()
+	for _, stats := range []*models.Stats{&failedLongAgo, &recovered} {
+		if err := db.Store.InsertStats(stats); err != nil {
+			t.Fatalf("Unexpected error when inserting stats: %v", err)
+		}
+	}
+
+	aggregate := func(halfLifeMinutes *int) *models.Stats {
+		results, err := db.Store.AggregatedStats(&models.StatsQuery{
+			Since:           now.Add(-12 * time.Hour),
+			Until:           now.Add(time.Minute),
+			HalfLifeMinutes: halfLifeMinutes,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error when aggregating stats: %v", err)
+		}
+		if len(results.Stats) != 1 {
+			t.Fatalf("Expected the stats of a single orchestrator, got %d", len(results.Stats))
+		}
+		return results.Stats[0]
+	}
+
+	// the active scoring configuration weights every test equally
+	if stats := aggregate(nil); stats.SuccessRate != 0.5 || stats.EffectiveTests != 0 {
+		t.Errorf("Expected the tests to be weighted equally by default, got %+v", stats)
+	}
+	flat := 0
+	if stats := aggregate(&flat); stats.SuccessRate != 0.5 || stats.EffectiveTests != 0 {
+		t.Errorf("Expected the tests to be weighted equally without a half-life, got %+v", stats)
+	}
+	// the failure is ten half-lives old and hardly counts, neither as a failure nor as a sample
+	hour := 60
+	stats := aggregate(&hour)
+	if stats.SuccessRate < 0.99 || stats.SuccessRate >= 1 {
+		t.Errorf("Expected the recent recovery to outweigh the old failure, got a success rate of %v", stats.SuccessRate)
+	}
+	if stats.Tests != 2 || stats.EffectiveTests < 1 || stats.EffectiveTests > 1.01 {
+		t.Errorf("Expected 2 tests as reliable as a single one, got %d tests and %v effective tests", stats.Tests, stats.EffectiveTests)
+	}
+}
+
+func TestPostgresAggregatedStatsByModel(t *testing.T) {
+	testutils.NewDB(t)
+	now := time.Now().UTC()
+
+	best := testutils.GetBestAIStats()
+	slow := testutils.GetBestAIStats()
+	slow.Orchestrator = "orch2"
+	slow.RoundTripTime = 4.2
+	otherModel := testutils.GetBestAIStats()
+	otherModel.Model = "model2"
+	for _, stats := range []*models.Stats{&best, &slow, &otherModel} {
+		if err := db.Store.InsertStats(stats); err != nil {
+			t.Fatalf("Unexpected error when inserting stats: %v", err)
+		}
+	}
+
+	query := &models.StatsQuery{Since: now.Add(-time.Hour), Until: now.Add(time.Minute), Orchestrators: []string{best.Orchestrator}, JobType: models.AI}
+	results, err := db.Store.AggregatedStatsByModel(query)
+	if err != nil {
+		t.Fatalf("Unexpected error when aggregating stats: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected the stats of 2 models, got %d", len(results))
+	}
+	for _, result := range results {
+		if len(result.Stats) != 1 || result.Stats[0].Orchestrator != best.Orchestrator {
+			t.Fatalf("Expected only the stats of the orchestrator, got %+v", result.Stats)
+		}
+		// the median RTT of each model is taken across all orchestrators
+		medianRTT, err := db.Store.MedianRTT(&models.StatsQuery{Since: query.Since, Until: query.Until, Pipelines: []string{best.Pipeline}, Models: []string{result.Stats[0].Model}})
+		if err != nil {
+			t.Fatalf("Unexpected error when calculating the median RTT: %v", err)
+		}
+		if result.MedianRTT != medianRTT {
+			t.Errorf("Unexpected median RTT of %v: got %v want %v", result.Stats[0].Model, result.MedianRTT, medianRTT)
+		}
+	}
+}
+
 func TestPostgresInsertStats(t *testing.T) {
 
 	type testCase struct {
@@ -146,6 +261,8 @@ func TestPostgresAggregatedStats(t *testing.T) {
 	aiStatsNewOrch2AndPipeline.Errors = nil
 	aiStatsNewOrch2AndPipeline.ResponsePayload = ""
 	aiStatsNewOrch2AndPipeline.InputParameters = ""
+	aiStatsNewOrch2AndPipeline.Cold = &models.ModelStateStats{RoundTripTime: aiStatsNewOrch2AndPipeline.RoundTripTime, Tests: 1}
+	aiStatsNewOrch2AndPipeline.Tests = 1
 
 	type testCase struct {
 		name          string
@@ -166,20 +283,20 @@ func TestPostgresAggregatedStats(t *testing.T) {
 		{
 			name: "Test with valid AI input and query params",
 			statsQuery: &models.StatsQuery{
-				Since:    testutils.GetUnixTimeMinusTenSec(),
-				Until:    testutils.GetUnixTimeInFiveSec(),
-				Pipeline: aiStats.Pipeline,
-				Model:    aiStats.Model,
+				Since:     testutils.GetUnixTimeMinusTenSec(),
+				Until:     testutils.GetUnixTimeInFiveSec(),
+				Pipelines: []string{aiStats.Pipeline},
+				Models:    []string{aiStats.Model},
 			},
 			statsToTest: []models.Stats{aiStats},
 		},
 		{
 			name: "Test with multiple valid AI input and query params",
 			statsQuery: &models.StatsQuery{
-				Since:    testutils.GetUnixTimeMinusTenSec(),
-				Until:    testutils.GetUnixTimeInFiveSec(),
-				Pipeline: aiStats.Pipeline,
-				Model:    aiStats.Model,
+				Since:     testutils.GetUnixTimeMinusTenSec(),
+				Until:     testutils.GetUnixTimeInFiveSec(),
+				Pipelines: []string{aiStats.Pipeline},
+				Models:    []string{aiStats.Model},
 			},
 			statsToTest: []models.Stats{aiStats, aiStatsFailed, aiStatsSlow},
 			expectedStats: []*models.Stats{
@@ -192,16 +309,18 @@ func TestPostgresAggregatedStats(t *testing.T) {
 					Timestamp:     0,
 					Model:         testutils.GetModel(),
 					Pipeline:      testutils.GetPipeline(),
+					Cold:          &models.ModelStateStats{SuccessRate: 0.3333333333333333, RoundTripTime: 6.54381758, Tests: 3},
+					Tests:         3,
 				},
 			},
 		},
 		{
 			name: "Test with multiple Orchs with valid AI input and query params",
 			statsQuery: &models.StatsQuery{
-				Since:    testutils.GetUnixTimeMinusTenSec(),
-				Until:    testutils.GetUnixTimeInFiveSec(),
-				Pipeline: aiStats.Pipeline,
-				Model:    aiStats.Model,
+				Since:     testutils.GetUnixTimeMinusTenSec(),
+				Until:     testutils.GetUnixTimeInFiveSec(),
+				Pipelines: []string{aiStats.Pipeline},
+				Models:    []string{aiStats.Model},
 			},
 			statsToTest: []models.Stats{aiStats, aiStatsFailed, aiStatsSlow, aiStatsNewOrchAndRegion},
 			expectedStats: []*models.Stats{
@@ -214,6 +333,8 @@ func TestPostgresAggregatedStats(t *testing.T) {
 					Timestamp:     0,
 					Model:         testutils.GetModel(),
 					Pipeline:      testutils.GetPipeline(),
+					Cold:          &models.ModelStateStats{SuccessRate: 0.3333333333333333, RoundTripTime: 6.54381758, Tests: 3},
+					Tests:         3,
 				},
 				{
 					Region:        "FRA",
@@ -221,16 +342,65 @@ func TestPostgresAggregatedStats(t *testing.T) {
 					RoundTripTime: 2.21572637,
 					Model:         testutils.GetModel(),
 					Pipeline:      testutils.GetPipeline(),
+					Cold:          &models.ModelStateStats{RoundTripTime: 2.21572637, Tests: 1},
+					Tests:         1,
+				},
+			},
+		},
+		{
+			name: "Test with fewer tests than the minimum number of samples",
+			statsQuery: &models.StatsQuery{
+				Since:      testutils.GetUnixTimeMinusTenSec(),
+				Until:      testutils.GetUnixTimeInFiveSec(),
+				Pipelines:  []string{aiStats.Pipeline},
+				Models:     []string{aiStats.Model},
+				MinSamples: 2,
+			},
+			statsToTest: []models.Stats{aiStats, aiStatsFailed, aiStatsSlow, aiStatsNewOrchAndRegion},
+			expectedStats: []*models.Stats{
+				{
+					Region:        "MDW",
+					Orchestrator:  testutils.GetOrchestratorID(),
+					SuccessRate:   0.3333333333333333,
+					RoundTripTime: 6.54381758,
+					Model:         testutils.GetModel(),
+					Pipeline:      testutils.GetPipeline(),
+					Cold:          &models.ModelStateStats{SuccessRate: 0.3333333333333333, RoundTripTime: 6.54381758, Tests: 3},
+					Tests:         3,
+				},
+			},
+		},
+		{
+			name: "Test with fewer tests than the minimum number of samples left out before the limit",
+			statsQuery: &models.StatsQuery{
+				Since:      testutils.GetUnixTimeMinusTenSec(),
+				Until:      testutils.GetUnixTimeInFiveSec(),
+				Pipelines:  []string{aiStats.Pipeline},
+				Models:     []string{aiStats.Model},
+				MinSamples: 2,
+				Limit:      1,
+			},
+			statsToTest: []models.Stats{aiStats, aiStatsFailed, aiStatsSlow, aiStatsNewOrchAndRegion},
+			expectedStats: []*models.Stats{
+				{
+					Region:        "MDW",
+					Orchestrator:  testutils.GetOrchestratorID(),
+					SuccessRate:   0.3333333333333333,
+					RoundTripTime: 6.54381758,
+					Model:         testutils.GetModel(),
+					Pipeline:      testutils.GetPipeline(),
+					Cold:          &models.ModelStateStats{SuccessRate: 0.3333333333333333, RoundTripTime: 6.54381758, Tests: 3},
+					Tests:         3,
 				},
 			},
 		},
 		{
 			name: "Test with multiple Orchs - variation 2 to test different averages scores from db",
 			statsQuery: &models.StatsQuery{
-				Since:    testutils.GetUnixTimeMinusTenSec(),
-				Until:    testutils.GetUnixTimeInFiveSec(),
-				Pipeline: aiStats.Pipeline,
-				Model:    aiStats.Model,
+				Since:     testutils.GetUnixTimeMinusTenSec(),
+				Until:     testutils.GetUnixTimeInFiveSec(),
+				Pipelines: []string{aiStats.Pipeline},
+				Models:    []string{aiStats.Model},
 			},
 			statsToTest: []models.Stats{aiStats, aiStatsSlow, aiStatsNewOrchAndRegion},
 			expectedStats: []*models.Stats{
@@ -243,6 +413,8 @@ func TestPostgresAggregatedStats(t *testing.T) {
 					Timestamp:     0,
 					Model:         testutils.GetModel(),
 					Pipeline:      testutils.GetPipeline(),
+					Cold:          &models.ModelStateStats{SuccessRate: 0.5, RoundTripTime: 8.707863184999999, Tests: 2},
+					Tests:         2,
 				},
 				{
 					Region:        "FRA",
@@ -250,20 +422,47 @@ func TestPostgresAggregatedStats(t *testing.T) {
 					RoundTripTime: 2.21572637,
 					Model:         testutils.GetModel(),
 					Pipeline:      testutils.GetPipeline(),
+					Cold:          &models.ModelStateStats{RoundTripTime: 2.21572637, Tests: 1},
+					Tests:         1,
 				},
 			},
 		},
 		{
 			name: "Test with multiple Orchs with different pipeline and model",
 			statsQuery: &models.StatsQuery{
-				Since:    testutils.GetUnixTimeMinusTenSec(),
-				Until:    testutils.GetUnixTimeInFiveSec(),
-				Pipeline: aiStatsNewOrch2AndPipeline.Pipeline,
-				Model:    aiStatsNewOrch2AndPipeline.Model,
+				Since:     testutils.GetUnixTimeMinusTenSec(),
+				Until:     testutils.GetUnixTimeInFiveSec(),
+				Pipelines: []string{aiStatsNewOrch2AndPipeline.Pipeline},
+				Models:    []string{aiStatsNewOrch2AndPipeline.Model},
 			},
 			statsToTest:   []models.Stats{aiStats, aiStatsFailed, aiStatsSlow, aiStatsNewOrchAndRegion, aiStatsNewOrch2AndPipeline},
 			expectedStats: []*models.Stats{&aiStatsNewOrch2AndPipeline},
 		},
+		{
+			name: "Test with several orchestrators, regions, pipelines and models",
+			statsQuery: &models.StatsQuery{
+				Since:         testutils.GetUnixTimeMinusTenSec(),
+				Until:         testutils.GetUnixTimeInFiveSec(),
+				Orchestrators: []string{aiStatsNewOrchAndRegion.Orchestrator, aiStatsNewOrch2AndPipeline.Orchestrator},
+				Regions:       []string{"FRA", "MDW"},
+				Pipelines:     []string{aiStats.Pipeline, aiStatsNewOrch2AndPipeline.Pipeline},
+				Models:        []string{aiStats.Model, aiStatsNewOrch2AndPipeline.Model},
+				SortFields:    []models.StatsQuerySortField{models.NewSortField("orchestrator", models.SortOrderAsc)},
+			},
+			statsToTest: []models.Stats{aiStats, aiStatsNewOrchAndRegion, aiStatsNewOrch2AndPipeline},
+			expectedStats: []*models.Stats{
+				{
+					Region:        "FRA",
+					Orchestrator:  "0x5c0e79538f4d17a668568c4031e4a1488d71df1b",
+					RoundTripTime: 2.21572637,
+					Model:         testutils.GetModel(),
+					Pipeline:      testutils.GetPipeline(),
+					Cold:          &models.ModelStateStats{RoundTripTime: 2.21572637, Tests: 1},
+					Tests:         1,
+				},
+				&aiStatsNewOrch2AndPipeline,
+			},
+		},
 	}
 
 	//run the tests in parallel
@@ -271,22 +470,21 @@ func TestPostgresAggregatedStats(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			testutils.NewDB(t)
 			common.Logger.Info("Running test: %s", tc.name)
-			var expectedAggStats []*models.Stats
+			//insert the stats objects into the database
 			for _, stats := range tc.statsToTest {
-
-				//insert the stats object into the database
-				for _, stats := range tc.statsToTest {
-					if err := db.Store.InsertStats(&stats); err != nil {
-						t.Fatalf("Unexpected error when inserting test stats: %v", err)
-					}
+				if err := db.Store.InsertStats(&stats); err != nil {
+					t.Fatalf("Unexpected error when inserting test stats: %v", err)
 				}
+			}
 
-				if tc.expectedStats != nil {
-					common.Logger.Info("Using expected stats from test case")
-					expectedAggStats = tc.expectedStats
-				} else {
-					common.Logger.Info("Using dynamically generated expected stats")
-					expectedAggStats = append(expectedAggStats, &models.Stats{
+			var expectedAggStats []*models.Stats
+			if tc.expectedStats != nil {
+				common.Logger.Info("Using expected stats from test case")
+				expectedAggStats = tc.expectedStats
+			} else {
+				common.Logger.Info("Using dynamically generated expected stats")
+				for _, stats := range tc.statsToTest {
+					expected := &models.Stats{
 						Orchestrator:  stats.Orchestrator,
 						Region:        stats.Region,
 						SuccessRate:   stats.SuccessRate,
@@ -294,7 +492,24 @@ func TestPostgresAggregatedStats(t *testing.T) {
 						RoundTripTime: stats.RoundTripTime,
 						Model:         stats.Model,
 						Pipeline:      stats.Pipeline,
-					})
+						Tests:         1,
+					}
+					// the test data was not run against a warm model
+					if stats.JobType() == models.AI.String() {
+						expected.Cold = &models.ModelStateStats{SuccessRate: stats.SuccessRate, RoundTripTime: stats.RoundTripTime, Tests: 1}
+					} else {
+						expected.Timing = &models.TranscodingTiming{
+							SegDuration:          stats.SegDuration,
+							RoundTripTime:        stats.RoundTripTime,
+							UploadTime:           stats.UploadTime,
+							DownloadTime:         stats.DownloadTime,
+							TranscodeTime:        stats.TranscodeTime,
+							SegmentsSent:         stats.SegmentsSent,
+							SegmentsReceived:     stats.SegmentsReceived,
+							SegmentDeliveryRatio: float64(stats.SegmentsReceived) / float64(stats.SegmentsSent),
+						}
+					}
+					expectedAggStats = append(expectedAggStats, expected)
 				}
 			}
 
@@ -336,13 +551,13 @@ func ValidateStats(t *testing.T, testStats *models.Stats) {
 
 	//get the stats object from the database
 	stats, err := db.Store.RawStats(&models.StatsQuery{
-		Orchestrator: testStats.Orchestrator,
+		Orchestrators: []string{testStats.Orchestrator},
 		//since 10 secs ago
 		Since: testutils.GetUnixTimeMinusTenSec(),
 		Until: testutils.GetUnixTimeInFiveSec(),
 
-		Pipeline: testStats.Pipeline,
-		Model:    testStats.Model,
+		Pipelines: testutils.Filter(testStats.Pipeline),
+		Models:    testutils.Filter(testStats.Model),
 	})
 	if err != nil {
 		t.Fatalf("Expected no error when retrieving raw stats, got %v", err)
@@ -0,0 +1,90 @@
+package score
+
+import (
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// ExplainScore shows how the score of the orchestrator in the region is calculated from the aggregated stats, the
+// same way as for CreateAggregatedStats.  It returns nil when the orchestrator has no stats in the region or is not
+// scored because it has no tests of the model state AI scores are based on.
+func ExplainScore(aggrStatsResults *models.AggregatedStatsResults, orchestrator, region string) *models.ScoreExplanation {
+	configs := loadScoringConfigs()
+	for _, stat := range aggrStatsResults.Stats {
+		if stat.Orchestrator != orchestrator || stat.Region != region {
+			continue
+		}
+		aggrStats, modelStateFormula := scoreAggregatedStat(stat, aggrStatsResults, configs)
+		if aggrStats == nil {
+			return nil
+		}
+		explanation := explainStat(stat, aggrStatsResults.MedianRTT, configs)
+		if stat.Warm != nil {
+			explanation.Warm = explainStat(modelStateStat(stat, stat.Warm), aggrStatsResults.WarmMedianRTT, configs)
+		}
+		if stat.Cold != nil {
+			explanation.Cold = explainStat(modelStateStat(stat, stat.Cold), aggrStatsResults.ColdMedianRTT, configs)
+		}
+		// the scores of the model states replace or are blended into the scores of all tests
+		if modelStateFormula != "" {
+			explanation.Steps = append(explanation.Steps, &models.ScoreStep{Name: "model_state_score", Formula: modelStateFormula, Value: aggrStats.TotalScore})
+		}
+		explanation.SuccessRate = aggrStats.SuccessRate
+		explanation.RoundTripScore = aggrStats.RoundTripScore
+		explanation.TotalScore = aggrStats.TotalScore
+		return explanation
+	}
+	return nil
+}
+
+// explainStat lists the steps the scores of a single aggregated stat are calculated in.  Scoring strategies that
+// are not an Explainer only show their RTT and total scores.
+func explainStat(stat *models.Stats, medianRTT float64, configs scoringConfigs) *models.ScoreExplanation {
+	config := configs.config(stat.JobType(), stat.Pipeline)
+	name := scorerNameFor(config)
+	scorer, _ := lookupScorer(name)
+	aggrStats := scoreStat(stat, medianRTT, configs)
+
+	explanation := &models.ScoreExplanation{
+		Orchestrator: stat.Orchestrator,
+		Region:       stat.Region,
+		JobType:      stat.JobType(),
+		Pipeline:     stat.Pipeline,
+		Model:        stat.Model,
+		Scorer:       name,
+		Config:       config,
+		Inputs: &models.ScoreInputs{
+			Tests:          stat.Tests,
+			EffectiveTests: stat.EffectiveTests,
+			SuccessRate:    stat.SuccessRate,
+			RoundTripTime:  stat.RoundTripTime,
+			MedianRTT:      medianRTT,
+		},
+		Steps:          []*models.ScoreStep{},
+		SuccessRate:    aggrStats.SuccessRate,
+		RoundTripScore: aggrStats.RoundTripScore,
+		TotalScore:     aggrStats.TotalScore,
+	}
+	if explanation.JobType == models.Transcoding.String() {
+		explanation.Inputs.SegDuration = stat.SegDuration
+	}
+
+	explainer, ok := scorer.(Explainer)
+	if ok {
+		explanation.Steps = append(explanation.Steps, explainer.ExplainRoundTripScore(stat, medianRTT, config)...)
+	} else {
+		explanation.Steps = append(explanation.Steps, &models.ScoreStep{Name: "round_trip_score", Formula: "calculated by the " + name + " scorer", Value: aggrStats.RoundTripScore})
+	}
+
+	// the total score is calculated with the estimated success rate
+	estimated := *aggrStats
+	estimated.SuccessRate = estimateSuccessRate(stat.SuccessRate, sampleSize(stat.Tests, stat.EffectiveTests), config)
+	if estimated.SuccessRate != stat.SuccessRate {
+		explanation.Steps = append(explanation.Steps, &models.ScoreStep{Name: "success_rate", Formula: "lower bound of the 95% Wilson score interval of the success rate over the tests", Value: estimated.SuccessRate})
+	}
+	if ok {
+		explanation.Steps = append(explanation.Steps, explainer.ExplainTotalScore(&estimated, config)...)
+	} else {
+		explanation.Steps = append(explanation.Steps, &models.ScoreStep{Name: "score", Formula: "calculated by the " + name + " scorer", Value: aggrStats.TotalScore})
+	}
+	return explanation
+}
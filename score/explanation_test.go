@@ -0,0 +1,101 @@
+package score
+
+import (
+	"math"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestExplainScore(t *testing.T) {
+	aiStats := testutils.GetBestAIStats()
+	aiStats.Tests = 4
+	aiStats.Cold = &models.ModelStateStats{SuccessRate: aiStats.SuccessRate, RoundTripTime: aiStats.RoundTripTime, Tests: 4}
+	transcodingStats := testutils.GetTranscodingStats()
+	transcodingStats.Tests = 2
+
+	tests := []struct {
+		name          string
+		results       *models.AggregatedStatsResults
+		stat          *models.Stats
+		expectedSteps []string
+	}{
+		{
+			name:          "AI",
+			results:       &models.AggregatedStatsResults{Stats: []*models.Stats{&aiStats}, MedianRTT: 2, ColdMedianRTT: 2},
+			stat:          &aiStats,
+			expectedSteps: []string{"k", "round_trip_score", "success_rate_term", "round_trip_score_term", "score"},
+		},
+		{
+			name:          "Transcoding",
+			results:       &models.AggregatedStatsResults{Stats: []*models.Stats{&transcodingStats}},
+			stat:          &transcodingStats,
+			expectedSteps: []string{"latency_score", "round_trip_score", "score"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation := ExplainScore(tt.results, tt.stat.Orchestrator, tt.stat.Region)
+			if explanation == nil {
+				t.Fatal("Expected an explanation")
+			}
+
+			// the explained scores are the same as those of the aggregated stats
+			aggrStats := CreateAggregatedStats(tt.results)[tt.stat.Orchestrator][tt.stat.Region]
+			if explanation.TotalScore != aggrStats.TotalScore || explanation.RoundTripScore != aggrStats.RoundTripScore {
+				t.Errorf("Unexpected scores: got %v and %v want %v and %v", explanation.TotalScore, explanation.RoundTripScore, aggrStats.TotalScore, aggrStats.RoundTripScore)
+			}
+			if explanation.Inputs.Tests != tt.stat.Tests || explanation.Inputs.MedianRTT != tt.results.MedianRTT {
+				t.Errorf("Unexpected inputs: %+v", explanation.Inputs)
+			}
+
+			steps := make(map[string]float64)
+			names := []string{}
+			for _, step := range explanation.Steps {
+				steps[step.Name] = step.Value
+				names = append(names, step.Name)
+			}
+			if len(names) != len(tt.expectedSteps) {
+				t.Fatalf("Unexpected steps: got %v want %v", names, tt.expectedSteps)
+			}
+			for i, name := range tt.expectedSteps {
+				if names[i] != name {
+					t.Fatalf("Unexpected steps: got %v want %v", names, tt.expectedSteps)
+				}
+			}
+			if steps["round_trip_score"] != aggrStats.RoundTripScore || steps["score"] != explanation.Steps[len(explanation.Steps)-1].Value {
+				t.Errorf("Expected the steps to end in the scores, got %v", steps)
+			}
+			if k, ok := steps["k"]; ok && math.Abs(k-(-math.Log(explanation.Config.DesiredScoreAtMedian)/tt.results.MedianRTT)) > 1e-12 {
+				t.Errorf("Unexpected k %v", k)
+			}
+		})
+	}
+
+	if explanation := ExplainScore(tests[0].results, aiStats.Orchestrator, "unknown"); explanation != nil {
+		t.Errorf("Expected no explanation for a region without stats, got %+v", explanation)
+	}
+}
+
+func TestExplainScoreWilsonEstimator(t *testing.T) {
+	configs := scoringConfigs{
+		{Version: 7, JobType: "transcoding", SuccessRateEstimator: SuccessRateWilson},
+	}
+	stat := testutils.GetTranscodingStats()
+	stat.Tests = 3
+
+	explanation := explainStat(&stat, 0, configs)
+	var estimated *models.ScoreStep
+	for _, step := range explanation.Steps {
+		if step.Name == "success_rate" {
+			estimated = step
+		}
+	}
+	if interval := wilsonInterval(stat.SuccessRate, float64(stat.Tests)); estimated == nil || estimated.Value != interval.Lower {
+		t.Fatalf("Expected the Wilson lower bound as a step, got %+v", explanation.Steps)
+	}
+	if explanation.Config.Version != 7 || explanation.Scorer != ScorerTranscodingLatency {
+		t.Errorf("Unexpected configuration %+v and scorer %v", explanation.Config, explanation.Scorer)
+	}
+}
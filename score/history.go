@@ -0,0 +1,64 @@
+package score
+
+import (
+	"sort"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// CreateScoreHistory scores every bucket against the median RTTs of that bucket and
+// returns one series per orchestrator, region and pipeline/model ordered by time.
+func CreateScoreHistory(buckets []*models.StatsBucket) map[string][]*models.ScoreSeries {
+	results := make(map[string][]*models.ScoreSeries)
+	seriesByKey := make(map[[4]string]*models.ScoreSeries)
+	common.Logger.Debug("Creating score history for %d buckets", len(buckets))
+	configs := loadScoringConfigs()
+
+	for _, bucket := range buckets {
+		if !bucket.Results.HasResults() {
+			continue
+		}
+		for _, stat := range bucket.Results.Stats {
+			aggrStats, _ := scoreAggregatedStat(stat, bucket.Results, configs)
+			if aggrStats == nil {
+				continue
+			}
+			key := [4]string{stat.Orchestrator, stat.Region, stat.Pipeline, stat.Model}
+			series, ok := seriesByKey[key]
+			if !ok {
+				series = &models.ScoreSeries{
+					Region:   stat.Region,
+					Pipeline: stat.Pipeline,
+					Model:    stat.Model,
+					Points:   []*models.ScorePoint{},
+				}
+				seriesByKey[key] = series
+				results[stat.Orchestrator] = append(results[stat.Orchestrator], series)
+			}
+			series.Points = append(series.Points, &models.ScorePoint{
+				Timestamp:       bucket.Start.Unix(),
+				AggregatedStats: aggrStats,
+			})
+		}
+	}
+
+	for _, orchSeries := range results {
+		sort.Slice(orchSeries, func(i, j int) bool {
+			a, b := orchSeries[i], orchSeries[j]
+			if a.Region != b.Region {
+				return a.Region < b.Region
+			}
+			if a.Pipeline != b.Pipeline {
+				return a.Pipeline < b.Pipeline
+			}
+			return a.Model < b.Model
+		})
+		for _, series := range orchSeries {
+			sort.Slice(series.Points, func(i, j int) bool {
+				return series.Points[i].Timestamp < series.Points[j].Timestamp
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,110 @@
+package score
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// CreateLeaderboard scores every aggregated stat and returns them ranked by the given sort field.
+// Ties on the sort field are broken by score, success rate and round trip score (all descending)
+// followed by orchestrator, region, pipeline and model (all ascending) so that the ranking is
+// always deterministic for the same set of stats.
+func CreateLeaderboard(aggrStatsResults *models.AggregatedStatsResults, sortField models.StatsQuerySortField) []*models.LeaderboardEntry {
+	return CreateLeaderboardWithConfigs(aggrStatsResults, sortField, loadScoringConfigs())
+}
+
+// CreateLeaderboardWithConfigs ranks the aggregated stats like CreateLeaderboard, scored with the given scoring
+// configurations instead of the active ones so that a configuration can be tried out before it is activated
+func CreateLeaderboardWithConfigs(aggrStatsResults *models.AggregatedStatsResults, sortField models.StatsQuerySortField, configs []*models.ScoringConfig) []*models.LeaderboardEntry {
+	entries := []*models.LeaderboardEntry{}
+	if !aggrStatsResults.HasResults() {
+		return entries
+	}
+	common.Logger.Debug("Creating leaderboard for %d stats sorted by %v", len(aggrStatsResults.Stats), sortField)
+
+	for _, stat := range aggrStatsResults.Stats {
+		aggrStats, _ := scoreAggregatedStat(stat, aggrStatsResults, configs)
+		if aggrStats == nil {
+			continue
+		}
+		entries = append(entries, &models.LeaderboardEntry{
+			Orchestrator:        stat.Orchestrator,
+			Region:              stat.Region,
+			Pipeline:            stat.Pipeline,
+			Model:               stat.Model,
+			SuccessRate:         aggrStats.SuccessRate,
+			RoundTripScore:      aggrStats.RoundTripScore,
+			TotalScore:          aggrStats.TotalScore,
+			ScoreVersion:        aggrStats.ScoreVersion,
+			Tests:               aggrStats.Tests,
+			EffectiveTests:      aggrStats.EffectiveTests,
+			SuccessRateInterval: aggrStats.SuccessRateInterval,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return lessEntry(entries[i], entries[j], sortField)
+	})
+	for i, entry := range entries {
+		entry.Rank = i + 1
+	}
+	return entries
+}
+
+// lessEntry reports whether entry a should be ranked ahead of entry b
+func lessEntry(a, b *models.LeaderboardEntry, sortField models.StatsQuerySortField) bool {
+	if c := compareEntryField(a, b, sortField.Field); c != 0 {
+		if sortField.Order == models.SortOrderDesc {
+			return c > 0
+		}
+		return c < 0
+	}
+
+	// tie breakers
+	for _, field := range []string{models.LeaderboardSortScore, models.LeaderboardSortSuccessRate, models.LeaderboardSortRoundTripScore} {
+		if c := compareEntryField(a, b, field); c != 0 {
+			return c > 0
+		}
+	}
+	for _, c := range []int{
+		strings.Compare(a.Orchestrator, b.Orchestrator),
+		strings.Compare(a.Region, b.Region),
+		strings.Compare(a.Pipeline, b.Pipeline),
+		strings.Compare(a.Model, b.Model),
+	} {
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
+
+// compareEntryField returns -1, 0 or 1 when the field of entry a is less than, equal to or greater than entry b
+func compareEntryField(a, b *models.LeaderboardEntry, field string) int {
+	switch field {
+	case models.LeaderboardSortScore:
+		return compareFloat64(a.TotalScore, b.TotalScore)
+	case models.LeaderboardSortSuccessRate:
+		return compareFloat64(a.SuccessRate, b.SuccessRate)
+	case models.LeaderboardSortRoundTripScore:
+		return compareFloat64(a.RoundTripScore, b.RoundTripScore)
+	case models.LeaderboardSortOrchestrator:
+		return strings.Compare(a.Orchestrator, b.Orchestrator)
+	case models.LeaderboardSortRegion:
+		return strings.Compare(a.Region, b.Region)
+	}
+	return 0
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
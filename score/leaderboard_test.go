@@ -0,0 +1,77 @@
+package score
+
+import (
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/testutils"
+)
+
+func TestCreateLeaderboardModelStateScoring(t *testing.T) {
+	newStats := func(orchestrator string, successRate float64, warm, cold *models.ModelStateStats) *models.Stats {
+		return &models.Stats{
+			Orchestrator:  orchestrator,
+			Region:        "FRA",
+			Pipeline:      testutils.GetPipeline(),
+			Model:         testutils.GetModel(),
+			SuccessRate:   successRate,
+			RoundTripTime: 1,
+			Tests:         10,
+			Warm:          warm,
+			Cold:          cold,
+		}
+	}
+	results := &models.AggregatedStatsResults{
+		Stats: []*models.Stats{
+			// fails every test against a cold model
+			newStats("orch1", 0.5, &models.ModelStateStats{SuccessRate: 1, RoundTripTime: 1, Tests: 5}, &models.ModelStateStats{SuccessRate: 0, RoundTripTime: 1, Tests: 5}),
+			newStats("orch2", 0.9, &models.ModelStateStats{SuccessRate: 0.8, RoundTripTime: 1, Tests: 5}, &models.ModelStateStats{SuccessRate: 1, RoundTripTime: 1, Tests: 5}),
+			// only tested against a cold model
+			newStats("orch3", 1, nil, &models.ModelStateStats{SuccessRate: 1, RoundTripTime: 1, Tests: 10}),
+		},
+		MedianRTT:     1,
+		WarmMedianRTT: 1,
+		ColdMedianRTT: 1,
+	}
+	sortField := models.NewSortField(models.LeaderboardSortScore, models.SortOrderDesc)
+
+	tests := []struct {
+		name       string
+		modelState string
+		expected   []string
+	}{
+		{name: "Blend of all tests", modelState: ModelStateBlend, expected: []string{"orch3", "orch2", "orch1"}},
+		{name: "Warm only", modelState: ModelStateWarm, expected: []string{"orch1", "orch2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultScoringConfig(models.AI.String())
+			config.Version = 3
+			config.ModelState = tt.modelState
+			configs := scoringConfigs{config}
+			entries := CreateLeaderboardWithConfigs(results, sortField, configs)
+			aggrStats := make(map[string]*models.AggregatedStats)
+			for _, stat := range results.Stats {
+				if stats, _ := scoreAggregatedStat(stat, results, configs); stats != nil {
+					aggrStats[stat.Orchestrator] = stats
+				}
+			}
+
+			if len(entries) != len(tt.expected) {
+				t.Fatalf("Unexpected number of entries: got %d want %d", len(entries), len(tt.expected))
+			}
+			for i, entry := range entries {
+				if entry.Orchestrator != tt.expected[i] {
+					t.Errorf("Unexpected orchestrator at rank %d: got %v want %v", entry.Rank, entry.Orchestrator, tt.expected[i])
+				}
+				// the leaderboard ranks the same scores as the aggregated stats
+				if stats := aggrStats[entry.Orchestrator]; stats == nil || stats.TotalScore != entry.TotalScore || stats.ScoreVersion != config.Version {
+					t.Errorf("Unexpected score of %v: got %v want the aggregated stats score %+v", entry.Orchestrator, entry.TotalScore, stats)
+				}
+			}
+			if len(aggrStats) != len(tt.expected) {
+				t.Errorf("Unexpected number of scored orchestrators: got %d want %d", len(aggrStats), len(tt.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package score
+
+import (
+	"math"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// Components that can drive a change of score
+const (
+	MovementDriverSuccessRate    = "success_rate"
+	MovementDriverRoundTripScore = "round_trip_score"
+	MovementDriverNone           = "none"
+)
+
+// AddRankMovement compares every entry of the current leaderboard with the same orchestrator, region,
+// pipeline and model on the leaderboard of the previous window.  Entries that were not ranked in the
+// previous window are left without a movement.
+func AddRankMovement(current []*models.LeaderboardEntry, previous []*models.LeaderboardEntry) {
+	previousEntries := make(map[string]*models.LeaderboardEntry, len(previous))
+	for _, entry := range previous {
+		previousEntries[entryKey(entry)] = entry
+	}
+
+	configs := loadScoringConfigs()
+	for _, entry := range current {
+		prev, ok := previousEntries[entryKey(entry)]
+		if !ok {
+			continue
+		}
+		entry.Movement = calculateMovement(entry, prev, configs)
+	}
+	common.Logger.Debug("Compared %d leaderboard entries with %d entries of the previous window", len(current), len(previous))
+}
+
+// calculateMovement splits the change of score into the part caused by the change of success rate
+// and the part caused by the change of RTT score.  The success rate is changed first, then the RTT
+// score, so that both parts add up to the total change for any scoring strategy.
+func calculateMovement(current, previous *models.LeaderboardEntry, configs scoringConfigs) *models.RankMovement {
+	jobType := (&models.Stats{Pipeline: current.Pipeline, Model: current.Model}).JobType()
+	config := configs.config(jobType, current.Pipeline)
+
+	successRateChanged := scorerFor(config).TotalScore(&models.AggregatedStats{
+		SuccessRate:    estimateSuccessRate(current.SuccessRate, sampleSize(current.Tests, current.EffectiveTests), config),
+		RoundTripScore: previous.RoundTripScore,
+	}, config)
+	successRateContribution := successRateChanged - previous.TotalScore
+	roundTripScoreContribution := current.TotalScore - successRateChanged
+
+	driver := MovementDriverNone
+	switch {
+	case successRateContribution == 0 && roundTripScoreContribution == 0:
+	case math.Abs(successRateContribution) >= math.Abs(roundTripScoreContribution):
+		driver = MovementDriverSuccessRate
+	default:
+		driver = MovementDriverRoundTripScore
+	}
+
+	return &models.RankMovement{
+		PreviousRank:               previous.Rank,
+		PreviousScore:              previous.TotalScore,
+		RankDelta:                  previous.Rank - current.Rank,
+		ScoreDelta:                 current.TotalScore - previous.TotalScore,
+		SuccessRateDelta:           current.SuccessRate - previous.SuccessRate,
+		RoundTripScoreDelta:        current.RoundTripScore - previous.RoundTripScore,
+		SuccessRateContribution:    successRateContribution,
+		RoundTripScoreContribution: roundTripScoreContribution,
+		Driver:                     driver,
+	}
+}
+
+func entryKey(entry *models.LeaderboardEntry) string {
+	return entry.Orchestrator + "/" + entry.Region + "/" + entry.Pipeline + "/" + entry.Model
+}
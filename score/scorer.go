@@ -0,0 +1,211 @@
+package score
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// Scorer is a scoring strategy.  It scores the RTT of an aggregated stat and combines it with the success rate
+// into the total score, using the parameters of the active scoring configuration.
+type Scorer interface {
+	// RoundTripScore scores the RTT of the stat between 0 and 1 given the median RTT of the stats it is ranked with
+	RoundTripScore(stat *models.Stats, medianRTT float64, config *models.ScoringConfig) float64
+	// TotalScore combines the success rate and RTT score of the stats into a score between 0 and 1
+	TotalScore(stats *models.AggregatedStats, config *models.ScoringConfig) float64
+}
+
+// Explainer is implemented by scoring strategies that can list the intermediate values of their scores, so that
+// the score explanation can show the math.  The value of the last step is the score.
+type Explainer interface {
+	ExplainRoundTripScore(stat *models.Stats, medianRTT float64, config *models.ScoringConfig) []*models.ScoreStep
+	ExplainTotalScore(stats *models.AggregatedStats, config *models.ScoringConfig) []*models.ScoreStep
+}
+
+// Names of the built-in scoring strategies
+const (
+	// ScorerAIExpDecay weights the success rate and an exponential decay of the RTT around the median RTT
+	ScorerAIExpDecay = "ai_exp_decay"
+	// ScorerTranscodingLatency multiplies the success rate by the ratio of the segment duration to the RTT
+	ScorerTranscodingLatency = "transcoding_latency"
+)
+
+var (
+	scorersMu sync.RWMutex
+	scorers   = map[string]Scorer{
+		ScorerAIExpDecay:         aiExpDecayScorer{},
+		ScorerTranscodingLatency: transcodingLatencyScorer{},
+	}
+)
+
+// Register makes a scoring strategy available under the given name so it can be configured for a job type or
+// pipeline.  It panics if the name is already registered or the scorer is nil.
+func Register(name string, scorer Scorer) {
+	scorersMu.Lock()
+	defer scorersMu.Unlock()
+	if scorer == nil {
+		panic("score: Register scorer is nil")
+	}
+	if _, dup := scorers[name]; dup {
+		panic("score: Register called twice for scorer " + name)
+	}
+	scorers[name] = scorer
+}
+
+func lookupScorer(name string) (Scorer, bool) {
+	scorersMu.RLock()
+	defer scorersMu.RUnlock()
+	scorer, ok := scorers[name]
+	return scorer, ok
+}
+
+// scorerFor returns the scoring strategy named by the scoring configuration or, when it names none, the default
+// scoring strategy of its job type
+func scorerFor(config *models.ScoringConfig) Scorer {
+	scorer, _ := lookupScorer(scorerNameFor(config))
+	return scorer
+}
+
+// scorerNameFor returns the name of the scoring strategy of the scoring configuration, see scorerFor.  Strategies
+// that are not registered fall back to the default strategy of the job type.
+func scorerNameFor(config *models.ScoringConfig) string {
+	fallback := ScorerTranscodingLatency
+	if config.JobType == models.AI.String() {
+		fallback = ScorerAIExpDecay
+	}
+	if config.Scorer == "" {
+		return fallback
+	}
+	if _, ok := lookupScorer(config.Scorer); ok {
+		return config.Scorer
+	}
+	common.Logger.Warn("Unknown scorer %q in version %d of the scoring config, using %v", config.Scorer, config.Version, fallback)
+	return fallback
+}
+
+// scoringConfigs are the active scoring configurations
+type scoringConfigs []*models.ScoringConfig
+
+// loadScoringConfigs loads the active scoring configurations.  When they can not be loaded the stats are scored
+// with the default parameters.
+func loadScoringConfigs() scoringConfigs {
+	if db.Store == nil {
+		return nil
+	}
+	configs, err := db.Store.ScoringConfigs()
+	if err != nil {
+		common.Logger.Error("Failed to load the scoring configs, the default parameters are used: %v", err)
+		return nil
+	}
+	return configs
+}
+
+// config returns the active scoring configuration of the pipeline or, when there is none, of the job type.  Without
+// an active configuration the default parameters are used, which are version 0.
+func (c scoringConfigs) config(jobType, pipeline string) *models.ScoringConfig {
+	if config := models.ActiveScoringConfig(c, jobType, pipeline); config != nil {
+		return config
+	}
+	return DefaultScoringConfig(jobType)
+}
+
+// DefaultScoringConfig returns the built-in parameters of the job type, which are version 0
+func DefaultScoringConfig(jobType string) *models.ScoringConfig {
+	return &models.ScoringConfig{
+		JobType:              jobType,
+		WeightSuccess:        weightSuccess,
+		WeightRTT:            weightRTT,
+		DesiredScoreAtMedian: desiredScoreAtMedian,
+		SuccessRateEstimator: SuccessRateMean,
+		ModelState:           ModelStateBlend,
+	}
+}
+
+// ValidateScoringConfig checks that the scoring strategy of the configuration is registered and that its success
+// rate estimator, model state and warm weight are known.  Empty values use the defaults and are valid.
+func ValidateScoringConfig(config *models.ScoringConfig) error {
+	if _, ok := lookupScorer(config.Scorer); config.Scorer != "" && !ok {
+		return fmt.Errorf("unknown scorer %q", config.Scorer)
+	}
+	switch config.SuccessRateEstimator {
+	case "", SuccessRateMean, SuccessRateWilson:
+	default:
+		return fmt.Errorf("success_rate_estimator must be '%v' or '%v', got %q", SuccessRateMean, SuccessRateWilson, config.SuccessRateEstimator)
+	}
+	switch config.ModelState {
+	case "", ModelStateBlend, ModelStateWarm, ModelStateCold:
+	default:
+		return fmt.Errorf("model_state must be '%v', '%v' or '%v', got %q", ModelStateBlend, ModelStateWarm, ModelStateCold, config.ModelState)
+	}
+	if config.WarmWeightPct != nil && (*config.WarmWeightPct < 0 || *config.WarmWeightPct > 100) {
+		return fmt.Errorf("warm_weight_pct must be between 0 and 100, got %d", *config.WarmWeightPct)
+	}
+	return nil
+}
+
+// aiExpDecayScorer is the default scoring strategy of AI jobs
+type aiExpDecayScorer struct{}
+
+func (aiExpDecayScorer) RoundTripScore(stat *models.Stats, medianRTT float64, config *models.ScoringConfig) float64 {
+	return normalizeAndCalcRTTScore(medianRTT, config.DesiredScoreAtMedian, stat)
+}
+
+func (aiExpDecayScorer) TotalScore(stats *models.AggregatedStats, config *models.ScoringConfig) float64 {
+	// if not stats or success rate is 0, return 0
+	if stats == nil || stats.SuccessRate == 0 {
+		return 0
+	}
+	return (config.WeightSuccess * stats.SuccessRate) + (config.WeightRTT * stats.RoundTripScore)
+}
+
+func (aiExpDecayScorer) ExplainRoundTripScore(stat *models.Stats, medianRTT float64, config *models.ScoringConfig) []*models.ScoreStep {
+	k := -math.Log(config.DesiredScoreAtMedian) / medianRTT
+	return []*models.ScoreStep{
+		{Name: "k", Formula: "-ln(desired_score_at_median) / median_rtt", Value: k},
+		{Name: "round_trip_score", Formula: "e^(-k * round_trip_time)", Value: normalizeAndCalcRTTScore(medianRTT, config.DesiredScoreAtMedian, stat)},
+	}
+}
+
+func (s aiExpDecayScorer) ExplainTotalScore(stats *models.AggregatedStats, config *models.ScoringConfig) []*models.ScoreStep {
+	if stats.SuccessRate == 0 {
+		return []*models.ScoreStep{{Name: "score", Formula: "0 when the success rate is 0", Value: 0}}
+	}
+	return []*models.ScoreStep{
+		{Name: "success_rate_term", Formula: "weight_success * success_rate", Value: config.WeightSuccess * stats.SuccessRate},
+		{Name: "round_trip_score_term", Formula: "weight_rtt * round_trip_score", Value: config.WeightRTT * stats.RoundTripScore},
+		{Name: "score", Formula: "success_rate_term + round_trip_score_term", Value: s.TotalScore(stats, config)},
+	}
+}
+
+// transcodingLatencyScorer is the default scoring strategy of transcoding jobs.  It has no parameters.
+type transcodingLatencyScorer struct{}
+
+func (transcodingLatencyScorer) RoundTripScore(stat *models.Stats, medianRTT float64, config *models.ScoringConfig) float64 {
+	return normalizeLatencyScore(calculateLatencyScore(stat))
+}
+
+func (transcodingLatencyScorer) TotalScore(stats *models.AggregatedStats, config *models.ScoringConfig) float64 {
+	// if not stats or success rate is 0, return 0
+	if stats == nil || stats.SuccessRate == 0 {
+		return 0
+	}
+	return stats.SuccessRate * stats.RoundTripScore
+}
+
+func (transcodingLatencyScorer) ExplainRoundTripScore(stat *models.Stats, medianRTT float64, config *models.ScoringConfig) []*models.ScoreStep {
+	latencyScore := calculateLatencyScore(stat)
+	return []*models.ScoreStep{
+		{Name: "latency_score", Formula: "seg_duration / round_trip_time, 0 without a round trip time", Value: latencyScore},
+		{Name: "round_trip_score", Formula: "1 - e^(-latency_score)", Value: normalizeLatencyScore(latencyScore)},
+	}
+}
+
+func (s transcodingLatencyScorer) ExplainTotalScore(stats *models.AggregatedStats, config *models.ScoringConfig) []*models.ScoreStep {
+	return []*models.ScoreStep{
+		{Name: "score", Formula: "success_rate * round_trip_score", Value: s.TotalScore(stats, config)},
+	}
+}
@@ -0,0 +1,113 @@
+package score
+
+import (
+	"math"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// successOnlyScorer ignores the RTT and scores the success rate only
+type successOnlyScorer struct{}
+
+func (successOnlyScorer) RoundTripScore(stat *models.Stats, medianRTT float64, config *models.ScoringConfig) float64 {
+	return 1
+}
+
+func (successOnlyScorer) TotalScore(stats *models.AggregatedStats, config *models.ScoringConfig) float64 {
+	return stats.SuccessRate
+}
+
+func init() {
+	Register("test_success_only", successOnlyScorer{})
+}
+
+func TestScorerFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *models.ScoringConfig
+		expected Scorer
+	}{
+		{name: "Transcoding default", config: &models.ScoringConfig{JobType: models.Transcoding.String()}, expected: transcodingLatencyScorer{}},
+		{name: "AI default", config: &models.ScoringConfig{JobType: models.AI.String(), Pipeline: "Image to image"}, expected: aiExpDecayScorer{}},
+		{name: "Configured strategy", config: &models.ScoringConfig{JobType: models.AI.String(), Pipeline: "Text to image", Scorer: "test_success_only"}, expected: successOnlyScorer{}},
+		{name: "Unknown strategy falls back to the job type default", config: &models.ScoringConfig{JobType: models.AI.String(), Pipeline: "Upscale", Scorer: "unknown"}, expected: aiExpDecayScorer{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if scorer := scorerFor(tt.config); scorer != tt.expected {
+				t.Errorf("Unexpected scorer: got %T want %T", scorer, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateScoringConfig(t *testing.T) {
+	warmWeight, tooHeavy := 80, 101
+	tests := []struct {
+		name    string
+		config  *models.ScoringConfig
+		invalid bool
+	}{
+		{name: "Defaults", config: &models.ScoringConfig{JobType: models.AI.String()}},
+		{name: "Every parameter", config: &models.ScoringConfig{JobType: models.AI.String(), Scorer: "test_success_only", SuccessRateEstimator: SuccessRateWilson, ModelState: ModelStateBlend, WarmWeightPct: &warmWeight}},
+		{name: "Unknown scorer", config: &models.ScoringConfig{Scorer: "unknown"}, invalid: true},
+		{name: "Unknown success rate estimator", config: &models.ScoringConfig{SuccessRateEstimator: "median"}, invalid: true},
+		{name: "Unknown model state", config: &models.ScoringConfig{ModelState: "hot"}, invalid: true},
+		{name: "Warm weight out of range", config: &models.ScoringConfig{WarmWeightPct: &tooHeavy}, invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateScoringConfig(tt.config); (err != nil) != tt.invalid {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestScoringConfigs(t *testing.T) {
+	configs := scoringConfigs{
+		{Version: 4, JobType: "ai", WeightSuccess: 0.5, WeightRTT: 0.5, DesiredScoreAtMedian: 0.9},
+		{Version: 5, JobType: "ai", Pipeline: "Text to image", Scorer: "test_success_only"},
+		{Version: 6, JobType: "ai", Pipeline: "Upscale", Scorer: "unknown"},
+	}
+
+	if config := configs.config("ai", "Text to image"); config.Version != 5 || scorerFor(config) != (successOnlyScorer{}) {
+		t.Errorf("Expected the configuration and scorer of the pipeline, got %+v", config)
+	}
+	if config := configs.config("ai", "Image to image"); config.Version != 4 || scorerFor(config) != (aiExpDecayScorer{}) {
+		t.Errorf("Expected the configuration of the job type, got %+v", config)
+	}
+	if config := configs.config("ai", "Upscale"); scorerFor(config) != (aiExpDecayScorer{}) {
+		t.Errorf("Expected an unknown scorer to fall back to the scorer of the job type")
+	}
+	if config := configs.config("transcoding", ""); config.Version != 0 || config.WeightSuccess != weightSuccess || config.DesiredScoreAtMedian != desiredScoreAtMedian {
+		t.Errorf("Expected the default parameters without an active configuration, got %+v", config)
+	}
+}
+
+func TestDefaultScorers(t *testing.T) {
+	config := scoringConfigs{}.config(models.AI.String(), "")
+	aiStats := &models.AggregatedStats{SuccessRate: 0.5, RoundTripScore: 0.8}
+	if score := (aiExpDecayScorer{}).TotalScore(aiStats, config); score != weightSuccess*0.5+weightRTT*0.8 {
+		t.Errorf("Unexpected AI total score %v", score)
+	}
+	if score := (transcodingLatencyScorer{}).TotalScore(aiStats, config); score != 0.5*0.8 {
+		t.Errorf("Unexpected transcoding total score %v", score)
+	}
+	if score := (aiExpDecayScorer{}).TotalScore(&models.AggregatedStats{RoundTripScore: 0.8}, config); score != 0 {
+		t.Errorf("Expected no score without successful tests, got %v", score)
+	}
+	if score := (aiExpDecayScorer{}).RoundTripScore(&models.Stats{RoundTripTime: 2}, 2, config); score != desiredScoreAtMedian {
+		t.Errorf("Expected the RTT score at the median RTT to be %v, got %v", desiredScoreAtMedian, score)
+	}
+
+	// the parameters of the configuration are used
+	weighted := &models.ScoringConfig{WeightSuccess: 0.5, WeightRTT: 0.5, DesiredScoreAtMedian: 0.5}
+	if score := (aiExpDecayScorer{}).TotalScore(aiStats, weighted); score != 0.5*0.5+0.5*0.8 {
+		t.Errorf("Unexpected weighted AI total score %v", score)
+	}
+	if score := (aiExpDecayScorer{}).RoundTripScore(&models.Stats{RoundTripTime: 2}, 2, weighted); math.Abs(score-0.5) > 1e-9 {
+		t.Errorf("Expected the RTT score at the median RTT to be 0.5, got %v", score)
+	}
+}
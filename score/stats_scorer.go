@@ -1,6 +1,7 @@
 package score
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/livepeer/leaderboard-serverless/common"
@@ -13,41 +14,36 @@ import (
 // Set k such that the score equals the desired score
 // at the median RTT.
 // Median was chosen to be more resilient to outliers.
+// It is only used when no scoring configuration is stored.
 const desiredScoreAtMedian float64 = 0.8
 
 // Weights for success rate and RTT score
-// in the final score calculation
+// in the final score calculation when no
+// scoring configuration is stored
 const weightSuccess float64 = 0.65
 const weightRTT float64 = 0.35
 
-func calculateTotalScore(stats *models.AggregatedStats, jobType string) float64 {
-	// if not stats or success rate is 0, return 0
-	if stats == nil || stats.SuccessRate == 0 {
-		return 0
-	}
-	if jobType == models.AI.String() {
-		return ((weightSuccess * stats.SuccessRate) + (weightRTT * stats.RoundTripScore))
-	}
-
-	return stats.SuccessRate * stats.RoundTripScore
-}
+// Model states AI scores can be based on
+const (
+	ModelStateBlend = "blend"
+	ModelStateWarm  = "warm"
+	ModelStateCold  = "cold"
+)
 
 func CreateAggregatedStats(aggrStatsResults *models.AggregatedStatsResults) map[string]map[string]*models.AggregatedStats {
 	results := make(map[string]map[string]*models.AggregatedStats)
 	common.Logger.Debug("Creating aggregated stats for %d stats", len(aggrStatsResults.Stats))
+	configs := loadScoringConfigs()
 
 	for _, stat := range aggrStatsResults.Stats {
+		aggrStats, _ := scoreAggregatedStat(stat, aggrStatsResults, configs)
+		if aggrStats == nil {
+			continue
+		}
 		_, ok := results[stat.Orchestrator]
 		if !ok {
 			results[stat.Orchestrator] = make(map[string]*models.AggregatedStats)
 		}
-		normalizedRTTScore := calculateRTTScore(stat, aggrStatsResults.MedianRTT)
-		aggrStats := &models.AggregatedStats{
-			ID:             stat.Orchestrator,
-			SuccessRate:    stat.SuccessRate,
-			RoundTripScore: normalizedRTTScore,
-		}
-		aggrStats.TotalScore = calculateTotalScore(aggrStats, stat.JobType())
 		results[stat.Orchestrator][stat.Region] = aggrStats
 
 		common.Logger.Trace("Stat object added with Orchestrator: %v, Region: %v, SuccessRate: %v, RoundTripTime: %v, SegDuration: %v, TotalScore: %v",
@@ -57,13 +53,156 @@ func CreateAggregatedStats(aggrStatsResults *models.AggregatedStatsResults) map[
 	return results
 }
 
-// Calculate the RTT score for a given stat
-func calculateRTTScore(stat *models.Stats, medianRtt float64) float64 {
+// AddErrorCounts adds the error counts by error code to the matching orchestrator and region of the aggregated stats
+func AddErrorCounts(results map[string]map[string]*models.AggregatedStats, errorCounts []*models.ErrorCount) {
+	for _, errorCount := range errorCounts {
+		aggrStats, ok := results[errorCount.Orchestrator][errorCount.Region]
+		if !ok {
+			continue
+		}
+		if aggrStats.Errors == nil {
+			aggrStats.Errors = make(map[string]int)
+		}
+		aggrStats.Errors[errorCount.ErrorCode] += errorCount.Count
+	}
+}
 
-	if stat.JobType() == models.AI.String() {
-		return normalizeAndCalcRTTScore(medianRtt, stat)
+// scoreAggregatedStat scores a single aggregated stat together with the stats of its model states.  Every score
+// returned by the API is calculated here so that it is the same on every endpoint.  It returns how the scores of
+// the model states were applied, which is empty when the scores of all tests are kept.  No stats are returned when
+// the stat is only scored on a model state it has no tests of.
+func scoreAggregatedStat(stat *models.Stats, aggrStatsResults *models.AggregatedStatsResults, configs scoringConfigs) (*models.AggregatedStats, string) {
+	aggrStats := scoreStat(stat, aggrStatsResults.MedianRTT, configs)
+	if stat.Warm == nil && stat.Cold == nil {
+		return aggrStats, ""
+	}
+	aggrStats.Warm = scoreModelState(stat, stat.Warm, aggrStatsResults.WarmMedianRTT, configs)
+	aggrStats.Cold = scoreModelState(stat, stat.Cold, aggrStatsResults.ColdMedianRTT, configs)
+	config := configs.config(stat.JobType(), stat.Pipeline)
+	formula, ok := applyModelStateScoring(aggrStats, config.ModelState, warmWeight(config))
+	if !ok {
+		common.Logger.Trace("Not scoring orchestrator %v in region %v, it has no %v tests", stat.Orchestrator, stat.Region, config.ModelState)
+		return nil, ""
+	}
+	return aggrStats, formula
+}
+
+// scoreStat calculates the RTT and total scores for a single aggregated stat with the active scoring configuration
+// and the scoring strategy of its job type and pipeline
+func scoreStat(stat *models.Stats, medianRTT float64, configs scoringConfigs) *models.AggregatedStats {
+	config := configs.config(stat.JobType(), stat.Pipeline)
+	scorer := scorerFor(config)
+	aggrStats := &models.AggregatedStats{
+		ID:                  stat.Orchestrator,
+		SuccessRate:         stat.SuccessRate,
+		RoundTripScore:      scorer.RoundTripScore(stat, medianRTT, config),
+		ScoreVersion:        config.Version,
+		Tests:               stat.Tests,
+		EffectiveTests:      stat.EffectiveTests,
+		SuccessRateInterval: wilsonInterval(stat.SuccessRate, sampleSize(stat.Tests, stat.EffectiveTests)),
+		Timing:              stat.Timing,
+	}
+	// the scorer sees the estimated success rate, the mean is still reported
+	estimated := *aggrStats
+	estimated.SuccessRate = estimateSuccessRate(stat.SuccessRate, sampleSize(stat.Tests, stat.EffectiveTests), config)
+	aggrStats.TotalScore = scorer.TotalScore(&estimated, config)
+	return aggrStats
+}
+
+// scoreModelState scores the AI stats of a single model state against the median RTT of that state
+func scoreModelState(stat *models.Stats, state *models.ModelStateStats, medianRTT float64, configs scoringConfigs) *models.AggregatedStats {
+	if state == nil {
+		return nil
 	}
-	return normalizeLatencyScore(calculateLatencyScore(stat))
+	return scoreStat(modelStateStat(stat, state), medianRTT, configs)
+}
+
+// modelStateStat returns a copy of the AI stat with the success rate, RTT and number of tests of a single model state
+func modelStateStat(stat *models.Stats, state *models.ModelStateStats) *models.Stats {
+	stateStat := *stat
+	stateStat.SuccessRate = state.SuccessRate
+	stateStat.RoundTripTime = state.RoundTripTime
+	stateStat.Tests = state.Tests
+	stateStat.EffectiveTests = state.EffectiveTests
+	stateStat.Warm = nil
+	stateStat.Cold = nil
+	return &stateStat
+}
+
+// warmWeight returns the weight of warm models in a blended AI score, which is negative when the warm and cold tests
+// are weighted by their number, the same as scoring all tests together
+func warmWeight(config *models.ScoringConfig) float64 {
+	if config.WarmWeightPct == nil {
+		return -1
+	}
+	return float64(*config.WarmWeightPct) / 100
+}
+
+// applyModelStateScoring replaces the scores of all tests with those of the configured model state.  It reports
+// false when the configured state was not tested, the stats can not be scored then.  A blend keeps the scores of
+// all tests unless a warm weight is configured, in which case the scores of both states are weighted or the only
+// tested state is used.  The number of tests and the success rate interval are those of the states applied.  It
+// returns the formula of the scores applied, which is empty when the scores of all tests are kept.
+func applyModelStateScoring(aggrStats *models.AggregatedStats, modelState string, warmWeight float64) (string, bool) {
+	warm, cold := aggrStats.Warm, aggrStats.Cold
+	if warm == nil && cold == nil {
+		return "", true
+	}
+
+	var state *models.AggregatedStats
+	switch modelState {
+	case ModelStateWarm:
+		state = warm
+	case ModelStateCold:
+		state = cold
+	default:
+		if warmWeight < 0 {
+			return "", true
+		}
+		if warm != nil && cold != nil {
+			weight := math.Min(warmWeight, 1)
+			setScores(aggrStats,
+				weight*warm.SuccessRate+(1-weight)*cold.SuccessRate,
+				weight*warm.RoundTripScore+(1-weight)*cold.RoundTripScore,
+				weight*warm.TotalScore+(1-weight)*cold.TotalScore)
+			aggrStats.Tests = warm.Tests + cold.Tests
+			aggrStats.EffectiveTests = blendedSampleSize(warm, cold, weight)
+			aggrStats.SuccessRateInterval = wilsonInterval(aggrStats.SuccessRate, aggrStats.EffectiveTests)
+			return fmt.Sprintf("%v * warm.score + %v * cold.score", weight, 1-weight), true
+		}
+		state = warm
+		if state == nil {
+			state = cold
+		}
+	}
+	if state == nil {
+		return "", false
+	}
+	setScores(aggrStats, state.SuccessRate, state.RoundTripScore, state.TotalScore)
+	aggrStats.Tests = state.Tests
+	aggrStats.EffectiveTests = state.EffectiveTests
+	aggrStats.SuccessRateInterval = state.SuccessRateInterval
+	if state == warm {
+		return "warm.score", true
+	}
+	return "cold.score", true
+}
+
+// blendedSampleSize returns the number of equally weighted tests the success rate blended from both model states is
+// as reliable as.  The variance of the blend is weight² / warm tests + (1 - weight)² / cold tests times that of a
+// single test.
+func blendedSampleSize(warm, cold *models.AggregatedStats, weight float64) float64 {
+	warmTests, coldTests := sampleSize(warm.Tests, warm.EffectiveTests), sampleSize(cold.Tests, cold.EffectiveTests)
+	if warmTests <= 0 || coldTests <= 0 {
+		return 0
+	}
+	return 1 / (weight*weight/warmTests + (1-weight)*(1-weight)/coldTests)
+}
+
+func setScores(aggrStats *models.AggregatedStats, successRate, roundTripScore, totalScore float64) {
+	aggrStats.SuccessRate = successRate
+	aggrStats.RoundTripScore = roundTripScore
+	aggrStats.TotalScore = totalScore
 }
 
 // Calculate the latency score for a given stat.  This function
@@ -96,12 +235,12 @@ func normalizeLatencyScore(score float64) float64 {
 
 // CalculateScores calculates the final scores for the given stats
 // using a combination of success rate and RTT scores through exponential decay E(x)=e^−kx
-func normalizeAndCalcRTTScore(medianRTT float64, stat *models.Stats) float64 {
+func normalizeAndCalcRTTScore(medianRTT float64, scoreAtMedian float64, stat *models.Stats) float64 {
 
 	common.Logger.Trace("Calculating RTT score for stat: %v and jobType: %v", stat, stat.JobType())
 
 	// Calculate k based on desired score at median RTT
-	k := -math.Log(desiredScoreAtMedian) / medianRTT
+	k := -math.Log(scoreAtMedian) / medianRTT
 
 	// Compute Exponential Decay Score for RTTs
 	expDecayScore := math.Exp(-k * stat.RoundTripTime)
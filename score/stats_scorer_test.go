@@ -1,6 +1,8 @@
 package score
 
 import (
+	"math"
+	"reflect"
 	"testing"
 
 	"github.com/livepeer/leaderboard-serverless/db"
@@ -54,10 +56,10 @@ func TestRTTScoreCalc(t *testing.T) {
 	}
 
 	statsQuery := &models.StatsQuery{
-		Model:    testutils.GetModel(),
-		Pipeline: testutils.GetPipeline(),
-		Since:    testutils.GetUnixTimeMinus24Hr(),
-		Until:    testutils.GetUnixTimeInFiveSec(),
+		Models:    []string{testutils.GetModel()},
+		Pipelines: []string{testutils.GetPipeline()},
+		Since:     testutils.GetUnixTimeMinus24Hr(),
+		Until:     testutils.GetUnixTimeInFiveSec(),
 	}
 	medianRTT, err := db.Store.MedianRTT(statsQuery)
 	if err != nil {
@@ -91,3 +93,59 @@ func TestRTTScoreCalc(t *testing.T) {
 
 	}
 }
+
+func TestApplyModelStateScoring(t *testing.T) {
+	warm := &models.AggregatedStats{SuccessRate: 1, RoundTripScore: 0.9, TotalScore: 0.965, Tests: 3, SuccessRateInterval: wilsonInterval(1, 3)}
+	cold := &models.AggregatedStats{SuccessRate: 0.5, RoundTripScore: 0.1, TotalScore: 0.36, Tests: 1, SuccessRateInterval: wilsonInterval(0.5, 1)}
+	all := models.AggregatedStats{SuccessRate: 0.875, RoundTripScore: 0.7, TotalScore: 0.81375, Tests: 4, SuccessRateInterval: wilsonInterval(0.875, 4)}
+
+	tests := []struct {
+		name       string
+		modelState string
+		warmWeight float64
+		warm       *models.AggregatedStats
+		cold       *models.AggregatedStats
+		expected   []float64
+		tests      int
+		sampleSize float64
+		unscored   bool
+	}{
+		{name: "Blend of all tests", modelState: ModelStateBlend, warmWeight: -1, warm: warm, cold: cold, expected: []float64{0.875, 0.7, 0.81375}, tests: 4, sampleSize: 4},
+		{name: "Warm only", modelState: ModelStateWarm, warmWeight: -1, warm: warm, cold: cold, expected: []float64{1, 0.9, 0.965}, tests: 3, sampleSize: 3},
+		{name: "Cold only", modelState: ModelStateCold, warmWeight: -1, warm: warm, cold: cold, expected: []float64{0.5, 0.1, 0.36}, tests: 1, sampleSize: 1},
+		{name: "Warm only without warm tests", modelState: ModelStateWarm, warmWeight: -1, cold: cold, unscored: true},
+		{name: "Cold only without cold tests", modelState: ModelStateCold, warmWeight: -1, warm: warm, unscored: true},
+		{name: "Weighted blend", modelState: ModelStateBlend, warmWeight: 0.5, warm: warm, cold: cold, expected: []float64{0.75, 0.5, 0.6625}, tests: 4, sampleSize: 3},
+		{name: "Weighted blend with a single state", modelState: ModelStateBlend, warmWeight: 0.5, warm: warm, expected: []float64{1, 0.9, 0.965}, tests: 3, sampleSize: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggrStats := all
+			aggrStats.Warm = tt.warm
+			aggrStats.Cold = tt.cold
+			_, ok := applyModelStateScoring(&aggrStats, tt.modelState, tt.warmWeight)
+			if ok == tt.unscored {
+				t.Fatalf("Unexpected scoring of the stats: got %v want %v", ok, !tt.unscored)
+			}
+			if tt.unscored {
+				return
+			}
+
+			got := []float64{aggrStats.SuccessRate, aggrStats.RoundTripScore, aggrStats.TotalScore}
+			for i := range got {
+				if math.Abs(got[i]-tt.expected[i]) > 1e-9 {
+					t.Errorf("Unexpected success rate, RTT score and total score: got %v want %v", got, tt.expected)
+					break
+				}
+			}
+			// the tests and interval describe the tests the scores are based on
+			if aggrStats.Tests != tt.tests || math.Abs(sampleSize(aggrStats.Tests, aggrStats.EffectiveTests)-tt.sampleSize) > 1e-9 {
+				t.Errorf("Unexpected tests: got %d tests and %v effective tests want %d and a sample size of %v", aggrStats.Tests, aggrStats.EffectiveTests, tt.tests, tt.sampleSize)
+			}
+			if interval := wilsonInterval(aggrStats.SuccessRate, tt.sampleSize); !reflect.DeepEqual(aggrStats.SuccessRateInterval, interval) {
+				t.Errorf("Unexpected success rate interval: got %+v want %+v", aggrStats.SuccessRateInterval, interval)
+			}
+		})
+	}
+}
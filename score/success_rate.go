@@ -0,0 +1,58 @@
+package score
+
+import (
+	"math"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// Estimators of the success rate used in the total score
+const (
+	// SuccessRateMean uses the average success rate of the tests
+	SuccessRateMean = "mean"
+	// SuccessRateWilson uses the lower bound of the Wilson score interval of the success rate so that a few
+	// successful tests do not outrank many mostly successful ones
+	SuccessRateWilson = "wilson"
+)
+
+// wilsonZ is the quantile of the standard normal distribution for 95% confidence
+const wilsonZ = 1.96
+
+// wilsonInterval returns the 95% Wilson score interval of a success rate measured over the number of tests
+func wilsonInterval(successRate float64, tests float64) *models.ConfidenceInterval {
+	if tests <= 0 {
+		return nil
+	}
+	n := tests
+	p := math.Max(0, math.Min(successRate, 1))
+	z2 := wilsonZ * wilsonZ
+
+	denominator := 1 + z2/n
+	center := (p + z2/(2*n)) / denominator
+	margin := wilsonZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denominator
+	return &models.ConfidenceInterval{
+		Lower: math.Max(0, center-margin),
+		Upper: math.Min(1, center+margin),
+	}
+}
+
+// estimateSuccessRate returns the success rate the total score is based on with the estimator of the scoring
+// configuration.  The mean is used when the estimator is unknown or the number of tests is not known.
+func estimateSuccessRate(successRate float64, tests float64, config *models.ScoringConfig) float64 {
+	if config.SuccessRateEstimator != SuccessRateWilson {
+		return successRate
+	}
+	if interval := wilsonInterval(successRate, tests); interval != nil {
+		return interval.Lower
+	}
+	return successRate
+}
+
+// sampleSize returns the number of tests a success rate is measured over.  When the tests are weighted by their age
+// it is the effective number of tests, so that old tests do not narrow the interval as much as new ones.
+func sampleSize(tests int, effectiveTests float64) float64 {
+	if effectiveTests > 0 {
+		return effectiveTests
+	}
+	return float64(tests)
+}
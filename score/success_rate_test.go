@@ -0,0 +1,79 @@
+package score
+
+import (
+	"math"
+	"testing"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		successRate float64
+		tests       int
+		lower       float64
+		upper       float64
+	}{
+		{name: "Single successful test", successRate: 1, tests: 1, lower: 0.2065, upper: 1},
+		{name: "Single failed test", successRate: 0, tests: 1, lower: 0, upper: 0.7935},
+		{name: "Many mostly successful tests", successRate: 0.9, tests: 100, lower: 0.8256, upper: 0.9448},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval := wilsonInterval(tt.successRate, float64(tt.tests))
+			if interval == nil {
+				t.Fatal("Expected an interval")
+			}
+			if math.Abs(interval.Lower-tt.lower) > 0.0001 || math.Abs(interval.Upper-tt.upper) > 0.0001 {
+				t.Errorf("Unexpected interval: got [%v, %v] want [%v, %v]", interval.Lower, interval.Upper, tt.lower, tt.upper)
+			}
+		})
+	}
+	if interval := wilsonInterval(1, 0); interval != nil {
+		t.Errorf("Expected no interval without tests, got %+v", interval)
+	}
+}
+
+func TestWilsonEstimatorRanksSparseStatsLower(t *testing.T) {
+	configs := scoringConfigs{
+		{Version: 3, JobType: "ai", Scorer: "test_success_only", SuccessRateEstimator: SuccessRateWilson},
+	}
+	sparse := &models.Stats{Orchestrator: "sparse", Model: "model", Pipeline: "pipeline", SuccessRate: 1, Tests: 1}
+	dense := &models.Stats{Orchestrator: "dense", Model: "model", Pipeline: "pipeline", SuccessRate: 0.9, Tests: 100}
+
+	sparseStats, denseStats := scoreStat(sparse, 1, configs), scoreStat(dense, 1, configs)
+	if sparseStats.TotalScore >= denseStats.TotalScore {
+		t.Errorf("Expected a single successful test to score below 100 mostly successful ones, got %v and %v", sparseStats.TotalScore, denseStats.TotalScore)
+	}
+	if sparseStats.SuccessRate != 1 || sparseStats.Tests != 1 || sparseStats.SuccessRateInterval == nil {
+		t.Errorf("Expected the mean success rate, number of tests and interval to be reported, got %+v", sparseStats)
+	}
+
+	configs[0].SuccessRateEstimator = SuccessRateMean
+	if score := scoreStat(sparse, 1, configs).TotalScore; score != 1 {
+		t.Errorf("Expected the mean success rate to be scored, got %v", score)
+	}
+}
+
+func TestWilsonIntervalOverEffectiveTests(t *testing.T) {
+	configs := scoringConfigs{{Version: 3, JobType: "ai", SuccessRateEstimator: SuccessRateWilson, WeightSuccess: 1, DesiredScoreAtMedian: 0.8}}
+	stat := &models.Stats{Orchestrator: "orch", Model: "model", Pipeline: "pipeline", SuccessRate: 0.9, RoundTripTime: 1, Tests: 100}
+	weighted := *stat
+	// most of the tests are old enough to hardly count
+	weighted.EffectiveTests = 5
+
+	equalStats, weightedStats := scoreStat(stat, 1, configs), scoreStat(&weighted, 1, configs)
+	if interval := wilsonInterval(0.9, 5); *weightedStats.SuccessRateInterval != *interval {
+		t.Errorf("Expected the interval over the effective tests, got %+v want %+v", weightedStats.SuccessRateInterval, interval)
+	}
+	if weightedStats.SuccessRateInterval.Lower >= equalStats.SuccessRateInterval.Lower {
+		t.Errorf("Expected a wider interval over fewer effective tests, got %+v and %+v", weightedStats.SuccessRateInterval, equalStats.SuccessRateInterval)
+	}
+	if weightedStats.TotalScore >= equalStats.TotalScore {
+		t.Errorf("Expected the Wilson estimate over fewer effective tests to score lower, got %v and %v", weightedStats.TotalScore, equalStats.TotalScore)
+	}
+	if weightedStats.Tests != 100 || weightedStats.EffectiveTests != 5 {
+		t.Errorf("Expected the number of tests and effective tests to be reported, got %+v", weightedStats)
+	}
+}
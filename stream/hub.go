@@ -0,0 +1,152 @@
+package stream
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// subscriberBufferSize is the number of events buffered for each subscriber.
+// Events are dropped for subscribers that fall further behind.
+const subscriberBufferSize = 64
+
+// listenRetryDelay is the time to wait before listening again after the listener failed
+var listenRetryDelay = 5 * time.Second
+
+// Event is a newly ingested stats object
+type Event struct {
+	ID    int64
+	Stats *models.Stats
+}
+
+// ListenFunc listens for new stats and calls fn for each of them until the context is cancelled
+type ListenFunc func(ctx context.Context, fn func(id int64, stats *models.Stats)) error
+
+// Subscriber receives the events matching its query
+type Subscriber struct {
+	Events chan *Event
+	query  *models.StatsQuery
+}
+
+// Hub fans out new stats to all subscribers.  It only listens for new stats while it has subscribers.
+type Hub struct {
+	mu          sync.Mutex
+	listen      ListenFunc
+	subscribers map[*Subscriber]struct{}
+	cancel      context.CancelFunc
+}
+
+// DefaultHub is the hub shared by all requests of this instance and is fed by the database
+var DefaultHub = NewHub(func(ctx context.Context, fn func(id int64, stats *models.Stats)) error {
+	if err := db.CacheDB(); err != nil {
+		return err
+	}
+	return db.Store.ListenStats(ctx, fn)
+})
+
+// NewHub creates a hub that receives new stats from the given listen function
+func NewHub(listen ListenFunc) *Hub {
+	return &Hub{
+		listen:      listen,
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber for the events matching the query
+func (h *Hub) Subscribe(query *models.StatsQuery) *Subscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscriber := &Subscriber{
+		Events: make(chan *Event, subscriberBufferSize),
+		query:  query,
+	}
+	h.subscribers[subscriber] = struct{}{}
+
+	if h.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		h.cancel = cancel
+		go h.run(ctx)
+	}
+	common.Logger.Debug("Stream subscriber added, %d subscribers", len(h.subscribers))
+	return subscriber
+}
+
+// Unsubscribe removes the subscriber and stops listening when no subscribers remain
+func (h *Hub) Unsubscribe(subscriber *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers, subscriber)
+	if len(h.subscribers) == 0 && h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+	}
+	common.Logger.Debug("Stream subscriber removed, %d subscribers", len(h.subscribers))
+}
+
+// Publish sends the stats to every subscriber with a matching query
+func (h *Hub) Publish(id int64, stats *models.Stats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	event := &Event{ID: id, Stats: stats}
+	for subscriber := range h.subscribers {
+		if !Matches(subscriber.query, stats) {
+			continue
+		}
+		select {
+		case subscriber.Events <- event:
+		default:
+			common.Logger.Warn("Dropping event %d for a slow stream subscriber", id)
+		}
+	}
+}
+
+// run listens for new stats until the context is cancelled, listening again whenever the listener fails
+func (h *Hub) run(ctx context.Context) {
+	for {
+		err := h.listen(ctx, h.Publish)
+		if ctx.Err() != nil {
+			common.Logger.Debug("Stopped listening for new stats")
+			return
+		}
+		common.Logger.Error("Listening for new stats failed, retrying in %v: %v", listenRetryDelay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// Matches returns true if the stats match every filter set on the query
+func Matches(query *models.StatsQuery, stats *models.Stats) bool {
+	if query == nil {
+		return true
+	}
+	if len(query.Orchestrators) > 0 && !slices.ContainsFunc(query.Orchestrators, func(orchestrator string) bool {
+		return strings.EqualFold(orchestrator, stats.Orchestrator)
+	}) {
+		return false
+	}
+	if len(query.Regions) > 0 && !slices.Contains(query.Regions, stats.Region) {
+		return false
+	}
+	if query.JobType != models.Unknown && query.JobType.String() != stats.JobType() {
+		return false
+	}
+	if len(query.Pipelines) > 0 && !slices.Contains(query.Pipelines, stats.Pipeline) {
+		return false
+	}
+	if len(query.Models) > 0 && !slices.Contains(query.Models, stats.Model) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+func TestMatches(t *testing.T) {
+	aiStats := &models.Stats{Orchestrator: "0xABC", Region: "FRA", Pipeline: "text-to-image", Model: "some/model"}
+	transcodingStats := &models.Stats{Orchestrator: "0xabc", Region: "MDW"}
+
+	tests := []struct {
+		name     string
+		query    *models.StatsQuery
+		stats    *models.Stats
+		expected bool
+	}{
+		{"No filters", &models.StatsQuery{}, aiStats, true},
+		{"Orchestrator is case insensitive", &models.StatsQuery{Orchestrators: []string{"0xabc"}}, aiStats, true},
+		{"Different region", &models.StatsQuery{Regions: []string{"MDW"}}, aiStats, false},
+		{"One of several regions", &models.StatsQuery{Regions: []string{"MDW", "FRA"}}, aiStats, true},
+		{"AI job type", &models.StatsQuery{JobType: models.AI}, aiStats, true},
+		{"Transcoding job type", &models.StatsQuery{JobType: models.Transcoding}, aiStats, false},
+		{"Pipeline and model", &models.StatsQuery{Pipelines: []string{"text-to-image"}, Models: []string{"some/model"}}, aiStats, true},
+		{"Pipeline on transcoding", &models.StatsQuery{Pipelines: []string{"text-to-image"}}, transcodingStats, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := Matches(tt.query, tt.stats); actual != tt.expected {
+				t.Errorf("unexpected match result: got %v want %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	listening := make(chan struct{})
+	stopped := make(chan struct{})
+	hub := NewHub(func(ctx context.Context, fn func(id int64, stats *models.Stats)) error {
+		close(listening)
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+
+	fraSubscriber := hub.Subscribe(&models.StatsQuery{Regions: []string{"FRA"}})
+	mdwSubscriber := hub.Subscribe(&models.StatsQuery{Regions: []string{"MDW"}})
+	<-listening
+
+	hub.Publish(1, &models.Stats{Region: "FRA"})
+
+	select {
+	case event := <-fraSubscriber.Events:
+		if event.ID != 1 {
+			t.Errorf("unexpected event id: got %v want %v", event.ID, 1)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected an event for the FRA subscriber")
+	}
+	select {
+	case event := <-mdwSubscriber.Events:
+		t.Fatalf("unexpected event for the MDW subscriber: %v", event)
+	default:
+	}
+
+	// the hub stops listening once the last subscriber is gone
+	hub.Unsubscribe(fraSubscriber)
+	hub.Unsubscribe(mdwSubscriber)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the hub to stop listening")
+	}
+}
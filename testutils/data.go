@@ -135,3 +135,11 @@ func ConvertUnixTimeToStrimg(t time.Time) string {
 	unixFloat := float64(unixNano) / 1e9 // Convert nanoseconds to seconds with fractions
 	return strconv.FormatFloat(unixFloat, 'f', -1, 64)
 }
+
+// Filter returns the query filter matching a single value, or no filter when the value is empty
+func Filter(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
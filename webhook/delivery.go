@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/middleware/auth"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// Headers sent with every notification.  The signature is the hex encoded HMAC-SHA256 of the body using the secret of the webhook.
+const (
+	SignatureHeader = "X-Leaderboard-Signature"
+	EventHeader     = "X-Leaderboard-Event"
+	DeliveryHeader  = "X-Leaderboard-Delivery"
+)
+
+// maxAttempts is the number of times a notification is sent before it is marked as failed
+var maxAttempts = common.EnvOrDefault("WEBHOOK_MAX_ATTEMPTS", 5).(int)
+
+// retryBackoff is the wait before the first retry, it doubles with every further attempt
+var retryBackoff = time.Duration(common.EnvOrDefault("WEBHOOK_RETRY_BACKOFF_SECONDS", 60).(int)) * time.Second
+
+var client = &http.Client{
+	Timeout: time.Duration(common.EnvOrDefault("WEBHOOK_TIMEOUT_SECONDS", 10).(int)) * time.Second,
+}
+
+// deliver makes a single attempt to post the notification to the webhook and records the result on the delivery.
+// A failed attempt is scheduled for a retry until the maximum number of attempts is reached.
+func deliver(webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) {
+	delivery.Attempts++
+	delivery.LastAttemptAt = now.Unix()
+	delivery.StatusCode = 0
+	delivery.Error = ""
+
+	statusCode, err := post(webhook, delivery)
+	delivery.StatusCode = statusCode
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.NextAttemptAt = 0
+		return
+	}
+
+	common.Logger.Warn("Delivery %d to webhook %d failed on attempt %d: %v", delivery.ID, webhook.ID, delivery.Attempts, err)
+	delivery.Error = err.Error()
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = 0
+		return
+	}
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.NextAttemptAt = now.Add(retryBackoff << (delivery.Attempts - 1)).Unix()
+}
+
+// post sends the signed payload of the delivery and returns the status code of the response
+func post(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, auth.Sign(webhook.Secret, delivery.Payload))
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
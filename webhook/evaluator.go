@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/common"
+	"github.com/livepeer/leaderboard-serverless/db"
+	"github.com/livepeer/leaderboard-serverless/models"
+	"github.com/livepeer/leaderboard-serverless/score"
+)
+
+// bucketSize is the length of the buckets the window of a webhook is split into.  A breach has to last in every
+// bucket of the window for the webhook to be triggered.
+var bucketSize = time.Duration(common.EnvOrDefault("WEBHOOK_BUCKET_MINUTES", 5).(int)) * time.Minute
+
+// Evaluate scores the stats of every webhook over its duration and notifies the webhook for every orchestrator
+// and region that became triggered or resolved since the last evaluation.  Earlier deliveries that are due for a
+// retry are sent again.  It returns every delivery attempted.  A webhook that can not be evaluated is skipped and
+// evaluated again on the next run.
+func Evaluate(now time.Time) ([]*models.WebhookDelivery, error) {
+	webhooks, err := db.Store.Webhooks()
+	if err != nil {
+		return nil, err
+	}
+	webhooksByID := make(map[int64]*models.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		webhooksByID[webhook.ID] = webhook
+	}
+
+	// retries are sent first so new notifications are not delivered ahead of older ones
+	deliveries, err := db.Store.DueWebhookDeliveries(now)
+	if err != nil {
+		return nil, err
+	}
+	for _, delivery := range deliveries {
+		webhook, ok := webhooksByID[delivery.WebhookID]
+		if !ok {
+			continue
+		}
+		deliver(webhook, delivery, now)
+		updateDelivery(delivery)
+	}
+
+	for _, webhook := range webhooks {
+		newDeliveries, err := evaluateWebhook(webhook, now)
+		if err != nil {
+			common.Logger.Error("Failed to evaluate webhook %d: %v", webhook.ID, err)
+			continue
+		}
+		for _, delivery := range newDeliveries {
+			deliver(webhook, delivery, now)
+			updateDelivery(delivery)
+		}
+		deliveries = append(deliveries, newDeliveries...)
+	}
+	common.Logger.Info("Evaluated %d webhooks and attempted %d deliveries", len(webhooks), len(deliveries))
+	return deliveries, nil
+}
+
+// updateDelivery records the result of an attempt.  When it can not be recorded the delivery stays due and is
+// sent again on the next run.
+func updateDelivery(delivery *models.WebhookDelivery) {
+	if err := db.Store.UpdateWebhookDelivery(delivery); err != nil {
+		common.Logger.Error("Failed to record the attempt of webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// evaluateWebhook scores the stats of the webhook's duration and stores a delivery for every orchestrator and
+// region whose state changed together with their new states.  It returns the stored deliveries, which are due
+// right away so they are retried when the first attempt is interrupted.
+func evaluateWebhook(webhook *models.Webhook, now time.Time) ([]*models.WebhookDelivery, error) {
+	jobType, err := models.JobTypeFromString(webhook.JobType)
+	if err != nil {
+		return nil, err
+	}
+	// the scores depend on the median RTT of every orchestrator so the orchestrator is only filtered once scored
+	since := now.Add(-time.Duration(webhook.DurationMinutes) * time.Minute)
+	query := &models.StatsQuery{
+		Since:   since,
+		Until:   now,
+		JobType: jobType,
+	}
+	if webhook.Region != "" {
+		query.Regions = []string{webhook.Region}
+	}
+	if webhook.Pipeline != "" {
+		query.Pipelines = []string{webhook.Pipeline}
+		query.Models = []string{webhook.Model}
+	}
+	buckets, err := db.Store.StatsHistory(query, bucketSize)
+	if err != nil {
+		return nil, err
+	}
+	states, err := db.Store.WebhookStates(webhook.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first bucket is the one the window starts in
+	firstBucket := since.Truncate(bucketSize).Unix()
+	events, changed := transitions(webhook, score.CreateScoreHistory(buckets), firstBucket, states)
+	deliveries := []*models.WebhookDelivery{}
+	for _, event := range events {
+		event.WindowStart = query.Since.Unix()
+		event.WindowEnd = query.Until.Unix()
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event.Event,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now.Unix(),
+		})
+	}
+	if err := db.Store.InsertWebhookDeliveries(deliveries, changed); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// transitions compares the scored buckets with the previous states of the webhook and returns an event and a new
+// state for every orchestrator and region whose state changed.  An orchestrator becomes triggered when the metric
+// breaches the threshold in every bucket it was tested in, starting with the first bucket of the window, and is
+// resolved as soon as the latest bucket it was tested in no longer breaches it.  Orchestrators seen for the first
+// time only produce an event when they are triggered.  Orchestrators without stats keep their previous state.
+func transitions(webhook *models.Webhook, history map[string][]*models.ScoreSeries, firstBucket int64, states []*models.WebhookState) ([]*models.WebhookEvent, []*models.WebhookState) {
+	previous := make(map[[2]string]*models.WebhookState, len(states))
+	for _, state := range states {
+		previous[[2]string{state.Orchestrator, state.Region}] = state
+	}
+
+	events := []*models.WebhookEvent{}
+	changed := []*models.WebhookState{}
+	for orchestrator, orchSeries := range history {
+		if webhook.Orchestrator != "" && orchestrator != webhook.Orchestrator {
+			continue
+		}
+		for _, series := range orchSeries {
+			if len(series.Points) == 0 {
+				continue
+			}
+			region := series.Region
+			latest := series.Points[len(series.Points)-1]
+			value := metricValue(webhook.Metric, latest.AggregatedStats)
+
+			state, known := previous[[2]string{orchestrator, region}]
+			triggered := webhook.Breached(value)
+			if !known || !state.Triggered {
+				triggered = sustained(webhook, series.Points, firstBucket)
+			}
+			if known && state.Triggered == triggered {
+				continue
+			}
+			changed = append(changed, &models.WebhookState{WebhookID: webhook.ID, Orchestrator: orchestrator, Region: region, Triggered: triggered})
+			if !known && !triggered {
+				continue
+			}
+
+			event := models.WebhookEventResolved
+			if triggered {
+				event = models.WebhookEventTriggered
+			}
+			events = append(events, &models.WebhookEvent{
+				Event:        event,
+				WebhookID:    webhook.ID,
+				Orchestrator: orchestrator,
+				Region:       region,
+				JobType:      webhook.JobType,
+				Pipeline:     webhook.Pipeline,
+				Model:        webhook.Model,
+				Metric:       webhook.Metric,
+				Condition:    webhook.Condition,
+				Threshold:    webhook.Threshold,
+				Value:        value,
+				ScoreVersion: latest.AggregatedStats.ScoreVersion,
+			})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Orchestrator != events[j].Orchestrator {
+			return events[i].Orchestrator < events[j].Orchestrator
+		}
+		return events[i].Region < events[j].Region
+	})
+	return events, changed
+}
+
+// sustained reports whether the metric breached the threshold over the whole window, in the first bucket of the
+// window and in every bucket the orchestrator was tested in after it.  The points are ordered by time.
+func sustained(webhook *models.Webhook, points []*models.ScorePoint, firstBucket int64) bool {
+	if points[0].Timestamp > firstBucket {
+		return false
+	}
+	for _, point := range points {
+		if !webhook.Breached(metricValue(webhook.Metric, point.AggregatedStats)) {
+			return false
+		}
+	}
+	return true
+}
+
+func metricValue(metric string, stats *models.AggregatedStats) float64 {
+	switch metric {
+	case models.WebhookMetricSuccessRate:
+		return stats.SuccessRate
+	case models.WebhookMetricRoundTripScore:
+		return stats.RoundTripScore
+	}
+	return stats.TotalScore
+}
@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/livepeer/leaderboard-serverless/middleware/auth"
+	"github.com/livepeer/leaderboard-serverless/models"
+)
+
+// scoreSeries creates the series of a region with one five minute bucket per score, starting at the given time
+func scoreSeries(region string, start int64, scores ...float64) *models.ScoreSeries {
+	series := &models.ScoreSeries{Region: region}
+	for i, score := range scores {
+		series.Points = append(series.Points, &models.ScorePoint{
+			Timestamp:       start + int64(i)*300,
+			AggregatedStats: &models.AggregatedStats{TotalScore: score, SuccessRate: score, ScoreVersion: 2},
+		})
+	}
+	return series
+}
+
+func TestTransitions(t *testing.T) {
+	webhook := &models.Webhook{
+		ID:        1,
+		JobType:   "transcoding",
+		Metric:    models.WebhookMetricScore,
+		Condition: models.WebhookConditionBelow,
+		Threshold: 0.5,
+	}
+	var first int64 = 1726858800
+	history := map[string][]*models.ScoreSeries{
+		"orch1": {scoreSeries("FRA", first, 0.2, 0.3, 0.2), scoreSeries("LAX", first, 0.9, 0.9, 0.9)},
+		"orch2": {scoreSeries("FRA", first, 0.4, 0.3, 0.4)},
+		"orch3": {scoreSeries("FRA", first, 0.2, 0.3, 0.8)},
+	}
+	states := []*models.WebhookState{
+		// still triggered, nothing to send
+		{WebhookID: 1, Orchestrator: "orch1", Region: "FRA", Triggered: true},
+		// recovered in the latest bucket
+		{WebhookID: 1, Orchestrator: "orch3", Region: "FRA", Triggered: true},
+		// no stats in the window, the state is kept
+		{WebhookID: 1, Orchestrator: "orch4", Region: "FRA", Triggered: true},
+	}
+
+	events, changed := transitions(webhook, history, first, states)
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d: %+v", len(events), events)
+	}
+	if events[0].Orchestrator != "orch2" || events[0].Event != models.WebhookEventTriggered || events[0].Value != 0.4 || events[0].ScoreVersion != 2 {
+		t.Errorf("Unexpected first event: %+v", events[0])
+	}
+	if events[1].Orchestrator != "orch3" || events[1].Event != models.WebhookEventResolved || events[1].Value != 0.8 {
+		t.Errorf("Unexpected second event: %+v", events[1])
+	}
+
+	// orch1 in LAX is recorded as healthy without an event, orch2 triggered and orch3 resolved
+	if len(changed) != 3 {
+		t.Fatalf("Expected 3 changed states, got %d: %+v", len(changed), changed)
+	}
+	for _, state := range changed {
+		if state.Orchestrator == "orch4" || (state.Orchestrator == "orch1" && state.Region == "FRA") {
+			t.Errorf("Unexpected state change: %+v", state)
+		}
+	}
+}
+
+func TestTransitionsRequireASustainedBreach(t *testing.T) {
+	webhook := &models.Webhook{
+		ID:        1,
+		JobType:   "transcoding",
+		Metric:    models.WebhookMetricScore,
+		Condition: models.WebhookConditionBelow,
+		Threshold: 0.5,
+	}
+	var first int64 = 1726858800
+	history := map[string][]*models.ScoreSeries{
+		// a short outage inside a healthy window
+		"orch1": {scoreSeries("FRA", first, 0.9, 0.1, 0.1, 0.9)},
+		// an outage that started during the window
+		"orch2": {scoreSeries("FRA", first, 0.9, 0.9, 0.1, 0.1)},
+		// only tested since the middle of the window
+		"orch3": {scoreSeries("FRA", first+600, 0.1, 0.1)},
+		// breaching in every bucket it was tested in
+		"orch4": {scoreSeries("FRA", first, 0.1, 0.2, 0.1)},
+	}
+
+	events, changed := transitions(webhook, history, first, nil)
+	if len(events) != 1 || events[0].Orchestrator != "orch4" || events[0].Event != models.WebhookEventTriggered {
+		t.Errorf("Expected only orch4 to be triggered, got %+v", events)
+	}
+	if len(changed) != 4 {
+		t.Errorf("Expected a state for every orchestrator, got %+v", changed)
+	}
+}
+
+func TestTransitionsFilteredByOrchestrator(t *testing.T) {
+	webhook := &models.Webhook{
+		ID:           1,
+		Orchestrator: "orch2",
+		Metric:       models.WebhookMetricSuccessRate,
+		Condition:    models.WebhookConditionBelow,
+		Threshold:    0.9,
+	}
+	var first int64 = 1726858800
+	history := map[string][]*models.ScoreSeries{
+		"orch1": {scoreSeries("FRA", first, 0.5)},
+		"orch2": {scoreSeries("FRA", first, 0.5)},
+	}
+
+	events, _ := transitions(webhook, history, first, nil)
+	if len(events) != 1 || events[0].Orchestrator != "orch2" || events[0].Metric != models.WebhookMetricSuccessRate {
+		t.Errorf("Expected a single event for orch2, got %+v", events)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	webhook := &models.Webhook{ID: 1, Secret: "0123456789abcdef"}
+	payload := []byte(`{"event":"threshold.triggered"}`)
+	now := time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Signed delivery", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get(SignatureHeader) != auth.Sign(webhook.Secret, body) {
+				t.Errorf("Unexpected signature %v", r.Header.Get(SignatureHeader))
+			}
+			if r.Header.Get(EventHeader) != models.WebhookEventTriggered || r.Header.Get(DeliveryHeader) != "7" {
+				t.Errorf("Unexpected headers: %v", r.Header)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		webhook.URL = server.URL
+
+		delivery := &models.WebhookDelivery{ID: 7, Event: models.WebhookEventTriggered, Payload: payload, Status: models.WebhookDeliveryPending}
+		deliver(webhook, delivery, now)
+		if delivery.Status != models.WebhookDeliveryDelivered || delivery.Attempts != 1 || delivery.StatusCode != http.StatusNoContent || delivery.NextAttemptAt != 0 {
+			t.Errorf("Unexpected delivery: %+v", delivery)
+		}
+	})
+
+	t.Run("Retried with backoff until it fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		webhook.URL = server.URL
+
+		delivery := &models.WebhookDelivery{ID: 8, Event: models.WebhookEventTriggered, Payload: payload, Status: models.WebhookDeliveryPending}
+		deliver(webhook, delivery, now)
+		if delivery.Status != models.WebhookDeliveryPending || delivery.StatusCode != http.StatusServiceUnavailable || delivery.Error == "" {
+			t.Errorf("Unexpected delivery after the first attempt: %+v", delivery)
+		}
+		if delivery.NextAttemptAt != now.Add(retryBackoff).Unix() {
+			t.Errorf("Expected the first retry after %v, got %v", retryBackoff, time.Unix(delivery.NextAttemptAt, 0).Sub(now))
+		}
+
+		deliver(webhook, delivery, now)
+		if delivery.NextAttemptAt != now.Add(2*retryBackoff).Unix() {
+			t.Errorf("Expected the backoff to double, got %v", time.Unix(delivery.NextAttemptAt, 0).Sub(now))
+		}
+
+		for delivery.Attempts < maxAttempts {
+			deliver(webhook, delivery, now)
+		}
+		if delivery.Status != models.WebhookDeliveryFailed || delivery.NextAttemptAt != 0 {
+			t.Errorf("Expected the delivery to fail after %d attempts: %+v", maxAttempts, delivery)
+		}
+	})
+}